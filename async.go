@@ -0,0 +1,260 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what an async Logger does when its queue is full
+type OverflowPolicy uint8
+
+// OverflowPolicy values
+const (
+	OverflowBlock      OverflowPolicy = iota // wait until there is room in the queue
+	OverflowDropNewest                       // drop the record being logged
+	OverflowDropOldest                       // drop the oldest record in the queue
+)
+
+// StringToOverflowPolicy is a map to translate policy name to OverflowPolicy type
+var StringToOverflowPolicy = map[string]OverflowPolicy{
+	"block":       OverflowBlock,
+	"drop_newest": OverflowDropNewest,
+	"drop_oldest": OverflowDropOldest,
+}
+
+// asyncItem is a record queued with the Logger it's logged by, or a flush marker
+type asyncItem struct {
+	logger  *Logger
+	rec     *Record
+	flushed chan struct{} // closed when the marker is reached, rec is nil for markers
+}
+
+type asyncState struct {
+	dropped uint64 // first word for 64-bit alignment of atomic operations
+
+	ch     chan asyncItem
+	mu     sync.RWMutex // guards closing the channel against sending
+	closed bool
+	done   chan struct{}
+
+	markerMu sync.Mutex
+	markers  []chan struct{} // markers dropped from the queue, released after the next item is handled
+}
+
+// EnableAsync makes the Logger hand records over to its handlers in a background goroutine
+// through a queue of bufferSize records, so logging doesn't block on slow handlers.
+// Records of descendants propagating to the Logger go through the queue as well,
+// unless they have queues of their own. Call Close to stop it.
+// It's safe to call while logging, a queue of another size replaces the current one,
+// which is drained before it returns. On a Logger derived by With, it enables the Logger it's derived from.
+func (l *Logger) EnableAsync(bufferSize int) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	l = l.origin()
+	l.asyncMu.Lock()
+	defer l.asyncMu.Unlock()
+	old := l.asyncState()
+	if old != nil && cap(old.ch) == bufferSize {
+		return
+	}
+	as := &asyncState{
+		ch:   make(chan asyncItem, bufferSize),
+		done: make(chan struct{}),
+	}
+	go as.run()
+	l.async.Store(as)
+	if old != nil {
+		old.close()
+	}
+}
+
+// asyncState return the state of the queue of l, or nil if l isn't async
+func (l *Logger) asyncState() *asyncState {
+	as, _ := l.async.Load().(*asyncState)
+	return as
+}
+
+// SetOverflowPolicy set what to do when the queue of the async Logger is full.
+// On a Logger derived by With, it's set on the Logger it's derived from.
+func (l *Logger) SetOverflowPolicy(policy OverflowPolicy) {
+	atomic.StoreInt32(&l.origin().overflow, int32(policy))
+}
+
+// Dropped return the number of records dropped because the queue which records of l go through was full
+func (l *Logger) Dropped() uint64 {
+	if _, as := l.queue(); as != nil {
+		return atomic.LoadUint64(&as.dropped)
+	}
+	return 0
+}
+
+// Flush waits until all the records queued so far in the queue which records of l go through
+// have been handled, or ctx is done. Records queued afterwards aren't waited for.
+func (l *Logger) Flush(ctx context.Context) error {
+	if _, as := l.queue(); as != nil {
+		return as.flush(ctx)
+	}
+	return nil
+}
+
+// Close stops the async mode after all the queued records have been handled.
+// Records logged afterwards are handled synchronously.
+func (l *Logger) Close() error {
+	l = l.origin()
+	l.asyncMu.Lock()
+	defer l.asyncMu.Unlock()
+	if as := l.asyncState(); as != nil {
+		l.async.Store((*asyncState)(nil))
+		as.close()
+	}
+	return nil
+}
+
+// origin return the Logger which l is derived from by With, or l itself.
+func (l *Logger) origin() *Logger {
+	for l.derived {
+		l = l.Parent()
+	}
+	return l
+}
+
+// queue return the nearest async Logger in l and the ancestors which records of l propagate to,
+// and the state of its queue. The state is nil if there is no such Logger.
+func (l *Logger) queue() (*Logger, *asyncState) {
+	for lg := l; lg != nil; lg = lg.Parent() {
+		if as := lg.asyncState(); as != nil {
+			return lg, as
+		}
+		if !lg.Propagate() {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// dispatch queues the record if records of the Logger go through a queue, or handles it directly.
+func (l *Logger) dispatch(rec *Record) {
+	if owner, as := l.queue(); as != nil && as.enqueue(asyncItem{logger: l, rec: rec}, OverflowPolicy(atomic.LoadInt32(&owner.overflow))) {
+		return
+	}
+	l.Handle(rec)
+}
+
+// enqueue return false if the queue has been closed
+func (as *asyncState) enqueue(item asyncItem, policy OverflowPolicy) bool {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	if as.closed {
+		return false
+	}
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case as.ch <- item:
+		default:
+			atomic.AddUint64(&as.dropped, 1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case as.ch <- item:
+				return true
+			default:
+			}
+			select {
+			case old := <-as.ch:
+				as.discard(old)
+			default:
+			}
+		}
+	default:
+		as.ch <- item
+	}
+	return true
+}
+
+// close stops accepting records, and waits until the queued ones have been handled
+func (as *asyncState) close() {
+	as.mu.Lock()
+	if !as.closed {
+		as.closed = true
+		close(as.ch)
+	}
+	as.mu.Unlock()
+	<-as.done
+}
+
+// discard counts a dropped record, flush markers are kept until the next item is handled,
+// so the records before them are handled already.
+func (as *asyncState) discard(item asyncItem) {
+	if item.flushed == nil {
+		atomic.AddUint64(&as.dropped, 1)
+		return
+	}
+	as.markerMu.Lock()
+	as.markers = append(as.markers, item.flushed)
+	as.markerMu.Unlock()
+}
+
+func (as *asyncState) releaseMarkers() {
+	as.markerMu.Lock()
+	for _, flushed := range as.markers {
+		close(flushed)
+	}
+	as.markers = nil
+	as.markerMu.Unlock()
+}
+
+// flush queues a marker and waits until it's reached, or the queue is drained if it's closed.
+func (as *asyncState) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	as.mu.RLock()
+	if as.closed {
+		flushed = as.done
+	} else {
+		select {
+		case as.ch <- asyncItem{flushed: flushed}:
+		case <-ctx.Done():
+			as.mu.RUnlock()
+			return ctx.Err()
+		}
+	}
+	as.mu.RUnlock()
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (as *asyncState) run() {
+	defer close(as.done)
+	for item := range as.ch {
+		if item.flushed != nil {
+			close(item.flushed)
+		} else {
+			item.logger.Handle(item.rec)
+		}
+		as.releaseMarkers()
+	}
+	as.releaseMarkers()
+}
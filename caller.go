@@ -0,0 +1,135 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// CallerMode decides when a Logger captures the caller of records
+type CallerMode uint8
+
+// CallerMode values
+const (
+	CallerAlways CallerMode = iota // capture for every record (default)
+	CallerNever                    // never capture, LFile, SFile and Func are "???"
+	CallerAuto                     // capture if any handler, formatter or filter needs it
+)
+
+// StringToCallerMode is a map to translate mode name to CallerMode type
+var StringToCallerMode = map[string]CallerMode{
+	"always": CallerAlways,
+	"never":  CallerNever,
+	"auto":   CallerAuto,
+}
+
+// CallerNeeder is implemented by handlers, formatters and filters which can tell whether they use
+// the caller of records, that is LFile, SFile, Line, Func and PC. The others are assumed to use it.
+type CallerNeeder interface {
+	NeedsCaller() bool
+}
+
+func needsCaller(v interface{}) bool {
+	cn, ok := v.(CallerNeeder)
+	return !ok || cn.NeedsCaller()
+}
+
+// helpers is the set of the names of functions marked by Helper
+var helpers sync.Map
+
+// Helper marks the calling function as a logging helper, like testing.T.Helper.
+// Helpers are skipped looking for the caller of records, so wrappers of Logger
+// report the file and line of their callers.
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	helpers.Store(frame.Function, true)
+}
+
+func isHelper(funcname string) bool {
+	_, ok := helpers.Load(funcname)
+	return ok
+}
+
+// caller return the frame skip levels above the caller of caller, like runtime.Caller.
+// Helpers and the frames for which skipFrame return true are skipped as well.
+func caller(skip int, skipFrame func(funcname string) bool) (pc uintptr, file string, funcname string, line int) {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !isHelper(frame.Function) && (skipFrame == nil || !skipFrame(frame.Function)) {
+			return frame.PC, frame.File, frame.Function, frame.Line
+		}
+		if !more {
+			break
+		}
+	}
+	return 0, "???", "???", 0
+}
+
+// WithCallerSkip return a derived Logger which skips n more frames looking for the caller of records,
+// for wrappers of Logger. It shares level, filters and handlers with l like the one returned by With.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	lg := l.With()
+	lg.callerSkip += n
+	return lg
+}
+
+// SetCallerMode set when the Logger captures the caller of records, CallerAlways is the default.
+// On a Logger derived by With, it's set on the Logger it's derived from.
+func (l *Logger) SetCallerMode(mode CallerMode) {
+	atomic.StoreInt32(&l.origin().callerMode, int32(mode))
+}
+
+// captureCaller reports whether the caller of records should be captured
+func (l *Logger) captureCaller() bool {
+	switch CallerMode(atomic.LoadInt32(&l.origin().callerMode)) {
+	case CallerNever:
+		return false
+	case CallerAuto:
+		return l.needsCaller()
+	}
+	return true
+}
+
+// needsCaller reports whether any filter of l, or any handler which records propagate to needs the caller.
+func (l *Logger) needsCaller() bool {
+	for lg := l; lg != nil; lg = lg.Parent() {
+		if lg.GroupFilter.needsCaller() {
+			return true
+		}
+		if !lg.derived {
+			break
+		}
+	}
+	for lg := l; lg != nil; lg = lg.Parent() {
+		if lg.handlerGroup.needsCaller() {
+			return true
+		}
+		if !lg.Propagate() {
+			break
+		}
+	}
+	return false
+}
@@ -17,10 +17,13 @@
 package glogger
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // ConfigLoaderBuilder is a function which return a ConfigLoader.
@@ -46,38 +49,127 @@ func GetConfigLoaderBuilder(name string) ConfigLoaderBuilder {
 	return nil
 }
 
+// ConfigMode decides how loading a configuration treats the filters, formatters and handlers
+// registered already. Loggers with the same names are always reconfigured in place.
+type ConfigMode uint8
+
+// ConfigMode values
+const (
+	StrictMode  ConfigMode = iota // fail if a name has been registered
+	MergeMode                     // replace the ones with the same names, keep the others
+	ReplaceMode                   // unregister all of them before loading
+)
+
+// StringToConfigMode is a map to translate mode name to ConfigMode type
+var StringToConfigMode = map[string]ConfigMode{
+	"strict":  StrictMode,
+	"merge":   MergeMode,
+	"replace": ReplaceMode,
+}
+
+type configOptions struct {
+	mode ConfigMode
+	env  bool
+}
+
+// ConfigOption is an option of loading configuration
+type ConfigOption func(opts *configOptions)
+
+// WithConfigMode return an option setting ConfigMode, StrictMode is the default
+func WithConfigMode(mode ConfigMode) ConfigOption {
+	return func(opts *configOptions) {
+		opts.mode = mode
+	}
+}
+
+// WithoutEnv return an option disabling the environment variable interpolation and overrides
+func WithoutEnv() ConfigOption {
+	return func(opts *configOptions) {
+		opts.env = false
+	}
+}
+
+func newConfigOptions(opts []ConfigOption) *configOptions {
+	options := &configOptions{mode: StrictMode, env: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// configMu serializes loading configurations
+var configMu sync.Mutex
+
 // LoadConfig parse the json format configuration.
-func LoadConfig(config []byte) error {
-	var configMap map[string]map[string]map[string]interface{}
-	if err := json.Unmarshal(config, &configMap); err != nil {
-		return err
+// Nothing is changed if it fails, and loggers are reconfigured only after everything else succeeded.
+// The handlers replaced or unregistered, and no longer used by any Logger are closed.
+// Problems found by ValidateConfig are returned as ConfigErrors, except warnings.
+func LoadConfig(config []byte, opts ...ConfigOption) error {
+	options := newConfigOptions(opts)
+	cm, errs := checkConfig(config, options)
+	var fatal []error
+	for _, err := range errs {
+		if e, ok := err.(*ConfigError); !ok || !e.Warning {
+			fatal = append(fatal, err)
+		}
+	}
+	if len(fatal) > 0 {
+		return ConfigErrors(fatal)
 	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	return applyConfigMap(cm, options)
+}
 
-	processFunc := func(name string, conf map[string]interface{}, callback func(loader ConfigLoader)) error {
-		bn, yes := conf["builder"]
-		var builderName string
-		if !yes {
-			return fmt.Errorf("'build' field is required for section %s", name)
+func applyConfigMap(configMap configMap, options *configOptions) (err error) {
+	registers := []*Register{filterRegister, formatterRegister, handlerRegister}
+	snapshots := make([]map[string]interface{}, len(registers))
+	for i, r := range registers {
+		snapshots[i] = r.snapshot()
+	}
+	var built []Handler
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i, r := range registers {
+			r.restore(snapshots[i])
 		}
-		builderName = bn.(string)
-		builder := GetConfigLoaderBuilder(builderName)
-		if builder == nil {
-			return fmt.Errorf("unknown builder name: %s", builderName)
+		closeHandlers(built)
+	}()
+	if options.mode == ReplaceMode {
+		for _, r := range registers {
+			r.restore(make(map[string]interface{}))
+		}
+	}
+
+	register := func(r *Register, name string, v interface{}) error {
+		if options.mode == StrictMode {
+			return r.TryRegister(name, v)
 		}
+		r.Replace(name, v)
+		return nil
+	}
+
+	// the builders have been checked by validate
+	processFunc := func(path string, conf map[string]interface{}, callback func(loader ConfigLoader) error) error {
+		builder := GetConfigLoaderBuilder(conf["builder"].(string))
 		loader := builder()
 		if err := loader.LoadConfig(conf); err != nil {
-			return err
+			if h, ok := loader.(Handler); ok {
+				closeHandlers([]Handler{h})
+			}
+			return ConfigErrors(prefixErrors(path, err))
 		}
-		callback(loader)
-		return nil
+		return callback(loader)
 	}
 
 	filters, ok := configMap["filters"]
 	if ok {
 		for name, conf := range filters {
-			if err := processFunc(name, conf, func(loader ConfigLoader) {
+			if err := processFunc(joinPath("filters", name), conf, func(loader ConfigLoader) error {
 				filter := loader.(Filter)
-				RegisterFilter(name, filter)
+				return register(filterRegister, name, filter)
 			}); err != nil {
 				return err
 			}
@@ -86,12 +178,9 @@ func LoadConfig(config []byte) error {
 	formatters, ok := configMap["formatters"]
 	if ok {
 		for name, conf := range formatters {
-			if builder, ok := conf["builder"]; !ok || builder.(string) == "default" {
-				conf["builder"] = "github.com/Xuyuanp/glogger.DefaultFormatter"
-			}
-			if err := processFunc(name, conf, func(loader ConfigLoader) {
+			if err := processFunc(joinPath("formatters", name), conf, func(loader ConfigLoader) error {
 				formatter := loader.(Formatter)
-				RegisterFormatter(name, formatter)
+				return register(formatterRegister, name, formatter)
 			}); err != nil {
 				return err
 			}
@@ -100,48 +189,80 @@ func LoadConfig(config []byte) error {
 	handlers, ok := configMap["handlers"]
 	if ok {
 		for name, conf := range handlers {
-			if builder, ok := conf["builder"]; !ok || builder.(string) == "default" {
-				conf["builder"] = "github.com/Xuyuanp/glogger.StreamHandler"
-			}
-			if err := processFunc(name, conf, func(loader ConfigLoader) {
+			if err := processFunc(joinPath("handlers", name), conf, func(loader ConfigLoader) error {
 				handler := loader.(Handler)
-				RegisterHandler(name, handler)
+				built = append(built, handler)
+				return register(handlerRegister, name, handler)
 			}); err != nil {
 				return err
 			}
 		}
 	}
-	loggers, ok := configMap["loggers"]
-	if ok {
-		for name, conf := range loggers {
-			if name == "root" {
-				logger := GetLogger("root")
-				if err := logger.LoadConfig(conf); err != nil {
-					return err
-				}
-				continue
-			}
-			logger := NewLogger()
-			if err := logger.LoadConfig(conf); err != nil {
-				return err
-			}
-			RegisterLogger(name, logger)
+	// parse all the loggers before applying any of them
+	type loggerUpdate struct {
+		name string
+		conf *loggerConfig
+	}
+	var updates []loggerUpdate
+	for name, conf := range configMap["loggers"] {
+		lc, err := parseLoggerConfig(NewConfigChecker(joinPath("loggers", name), conf), true)
+		if err != nil {
+			return err
 		}
+		updates = append(updates, loggerUpdate{name, lc})
 	}
+	for _, u := range updates {
+		getOrCreateLogger(u.name).applyConfig(u.conf)
+	}
+	closeUnusedHandlers(snapshots[2])
 	return nil
 }
 
+// closeUnusedHandlers closes the handlers in the snapshot which have been replaced
+// or unregistered, and aren't used by any registered Logger, once the records being
+// handled with the handlers replaced in Loggers are done.
+func closeUnusedHandlers(snapshot map[string]interface{}) {
+	used := make(map[Handler]bool)
+	loggerRegister.Range(func(_ string, v interface{}) bool {
+		for _, h := range v.(*Logger).handlerList() {
+			used[h] = true
+		}
+		return true
+	})
+	var unused []Handler
+	for name, v := range snapshot {
+		if h := v.(Handler); GetHandler(name) != h && !used[h] {
+			unused = append(unused, h)
+		}
+	}
+	if len(unused) > 0 {
+		waitHandling()
+		closeHandlers(unused)
+	}
+}
+
+func closeHandlers(handlers []Handler) {
+	for _, h := range handlers {
+		if c, ok := h.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+}
+
 // LoadConfigFromFile read file's content and call the LoadConfig method.
-func LoadConfigFromFile(fileName string) error {
-	var err error
-	var file *os.File
-	if file, err = os.Open(fileName); err != nil {
+// Files with the extension .yaml, .yml or .toml are loaded by LoadConfigYAML or LoadConfigTOML.
+func LoadConfigFromFile(fileName string, opts ...ConfigOption) error {
+	code, err := ioutil.ReadFile(fileName)
+	if err != nil {
 		return err
 	}
-	defer file.Close()
-	if code, err := ioutil.ReadAll(file); err == nil {
-		return LoadConfig(code)
-	} else {
-		return err
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return LoadConfigYAML(code, opts...)
+	case ".toml":
+		return LoadConfigTOML(code, opts...)
 	}
+	return LoadConfig(code, opts...)
 }
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadConfigYAML parse the yaml format configuration, it has the same structure as the json one.
+func LoadConfigYAML(config []byte, opts ...ConfigOption) error {
+	data, err := yamlToJSON(config)
+	if err != nil {
+		return err
+	}
+	return LoadConfig(data, opts...)
+}
+
+// LoadConfigTOML parse the toml format configuration, it has the same structure as the json one.
+func LoadConfigTOML(config []byte, opts ...ConfigOption) error {
+	data, err := tomlToJSON(config)
+	if err != nil {
+		return err
+	}
+	return LoadConfig(data, opts...)
+}
+
+func yamlToJSON(config []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(config, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(v))
+}
+
+func tomlToJSON(config []byte) ([]byte, error) {
+	var v map[string]interface{}
+	if _, err := toml.Decode(string(config), &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(v))
+}
+
+// jsonCompatible converts the maps with non-string keys decoded by yaml,
+// and the typed slices decoded by toml, so that they can be marshaled to json.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = jsonCompatible(value)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = jsonCompatible(value)
+		}
+		return list
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = jsonCompatible(value)
+		}
+		return list
+	}
+	return v
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Config is the typed form of the json format configuration, it can be built in code,
+// marshaled to or unmarshaled from json, and applied by ApplyConfig.
+type Config struct {
+	Filters    map[string]ComponentConfig `json:"filters,omitempty"`
+	Formatters map[string]ComponentConfig `json:"formatters,omitempty"`
+	Handlers   map[string]ComponentConfig `json:"handlers,omitempty"`
+	Loggers    map[string]LoggerConfig    `json:"loggers,omitempty"`
+}
+
+// ComponentConfig is the configuration of a filter, formatter or handler.
+// Level, Formatter and Filters are used by handlers, the other fields are kept in Options.
+// They are all flattened into one json object.
+type ComponentConfig struct {
+	Builder   string
+	Level     string
+	Formatter string
+	Filters   []string
+	Options   map[string]interface{}
+}
+
+// MarshalJSON flattens the fields and Options into one object
+func (cc ComponentConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cc.toMap())
+}
+
+// UnmarshalJSON splits the object into the fields and Options
+func (cc *ComponentConfig) UnmarshalJSON(data []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c := NewConfigChecker("", m)
+	*cc = ComponentConfig{}
+	cc.Builder, _ = c.String("builder", false)
+	cc.Level, _ = c.String("level", false)
+	cc.Formatter, _ = c.String("formatter", false)
+	cc.Filters, _ = c.Strings("filters", false)
+	for key, value := range m {
+		switch key {
+		case "builder", "level", "formatter", "filters":
+		default:
+			if cc.Options == nil {
+				cc.Options = make(map[string]interface{})
+			}
+			cc.Options[key] = value
+		}
+	}
+	return c.Err()
+}
+
+func (cc ComponentConfig) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(cc.Options)+4)
+	for key, value := range cc.Options {
+		m[key] = value
+	}
+	if cc.Builder != "" {
+		m["builder"] = cc.Builder
+	}
+	if cc.Level != "" {
+		m["level"] = cc.Level
+	}
+	if cc.Formatter != "" {
+		m["formatter"] = cc.Formatter
+	}
+	if cc.Filters != nil {
+		m["filters"] = cc.Filters
+	}
+	return m
+}
+
+// LoggerConfig is the configuration of a Logger
+type LoggerConfig struct {
+	Level      string   `json:"level,omitempty"`
+	Propagate  *bool    `json:"propagate,omitempty"`
+	Handlers   []string `json:"handlers,omitempty"`
+	Filters    []string `json:"filters,omitempty"`
+	Async      int      `json:"async,omitempty"`
+	Overflow   string   `json:"overflow,omitempty"`
+	Caller     string   `json:"caller,omitempty"`
+	StackLevel string   `json:"stackLevel,omitempty"`
+}
+
+// ApplyConfig loads the Config in the same way as LoadConfig
+func ApplyConfig(cfg *Config, opts ...ConfigOption) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return LoadConfig(data, opts...)
+}
+
+// ConfigExporter is implemented by ConfigLoaders which can export their configuration,
+// in the form accepted by LoadConfig. The others are exported by marshaling them to json.
+type ConfigExporter interface {
+	ExportConfig() map[string]interface{}
+}
+
+// BuilderName return the name of the ConfigLoaderBuilder of v by convention,
+// the import path of its type and the type name, like "github.com/Xuyuanp/glogger.StreamHandler".
+func BuilderName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// CurrentConfig exports the filters, formatters, handlers and loggers registered for debugging.
+// Filters, formatters and handlers which aren't registered are omitted, as they can't be referred by name.
+func CurrentConfig() *Config {
+	cfg := &Config{
+		Filters:    exportComponents(filterRegister),
+		Formatters: exportComponents(formatterRegister),
+		Handlers:   exportComponents(handlerRegister),
+		Loggers:    make(map[string]LoggerConfig),
+	}
+	loggerRegister.Range(func(name string, v interface{}) bool {
+		cfg.Loggers[name] = v.(*Logger).exportConfig()
+		return true
+	})
+	return cfg
+}
+
+func exportComponents(r *Register) map[string]ComponentConfig {
+	components := make(map[string]ComponentConfig)
+	r.Range(func(name string, v interface{}) bool {
+		var m map[string]interface{}
+		if exporter, ok := v.(ConfigExporter); ok {
+			m = exporter.ExportConfig()
+		} else if data, err := json.Marshal(v); err == nil {
+			json.Unmarshal(data, &m)
+		}
+		data, _ := json.Marshal(m)
+		var cc ComponentConfig
+		json.Unmarshal(data, &cc)
+		cc.Builder = BuilderName(v)
+		components[name] = cc
+		return true
+	})
+	return components
+}
+
+// registeredName return the name v registered with, or "" if not found
+func registeredName(r *Register, v interface{}) string {
+	if v == nil || !reflect.TypeOf(v).Comparable() {
+		return ""
+	}
+	var found string
+	r.Range(func(name string, value interface{}) bool {
+		if value == v {
+			found = name
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// filterNames return the registered names of the filters
+func filterNames(filters []Filter) []string {
+	names := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if name := registeredName(filterRegister, f); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,294 @@
+package glogger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// restoreRegisters restores the registered filters, formatters and handlers after the test,
+// so it can load configurations in StrictMode repeatedly.
+func restoreRegisters(t *testing.T) {
+	for _, r := range []*Register{filterRegister, formatterRegister, handlerRegister} {
+		r, snapshot := r, r.snapshot()
+		t.Cleanup(func() { r.restore(snapshot) })
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "log.conf")
+	write := func(conf string) {
+		if err := ioutil.WriteFile(fileName, []byte(conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{
+		"handlers": {"watch-console": {"writer": "stderr", "level": "INFO"}},
+		"loggers": {"watch": {"level": "INFO", "handlers": ["watch-console"]}}
+	}`)
+	defer func(interval time.Duration) { WatchInterval = interval }(WatchInterval)
+	WatchInterval = 10 * time.Millisecond
+	w, err := WatchConfigFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterLogger("watch")
+
+	logger := GetLogger("watch")
+	old := GetHandler("watch-console")
+	if logger.Level() != InfoLevel {
+		t.Fatalf("level = %v, want %v", logger.Level(), InfoLevel)
+	}
+
+	write(`{
+		"handlers": {"watch-console": {"writer": "stderr", "level": "ERROR"}},
+		"loggers": {"watch": {"level": "ERROR", "handlers": ["watch-console"]}}
+	}`)
+	deadline := time.Now().Add(time.Second)
+	for GetHandler("watch-console") == old {
+		if time.Now().After(deadline) {
+			t.Fatal("config file not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Stop()
+	if GetLogger("watch") != logger || logger.Level() != ErrorLevel {
+		t.Errorf("logger should be kept and reconfigured")
+	}
+
+	write(`{
+		"handlers": {"watch-console": {"writer": "stdout"}},
+		"loggers": {"watch": {"level": "INFO", "handlers": ["missing"]}}
+	}`)
+	if err := w.Reload(); err == nil {
+		t.Fatal("expected error for unknown handler")
+	}
+	if logger.Level() != ErrorLevel || GetHandler("watch-console").Level() != ErrorLevel {
+		t.Errorf("failed reload should keep the working configuration")
+	}
+}
+
+func TestLoadConfigModes(t *testing.T) {
+	restoreRegisters(t)
+	var changed []string
+	remove := handlerRegister.AddListener(func(name string, old, v interface{}) {
+		if name == "modes-a" || name == "modes-b" {
+			changed = append(changed, name)
+		}
+	})
+	defer remove()
+
+	conf := []byte(`{"handlers": {"modes-a": {"writer": "stderr"}}}`)
+	if err := LoadConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	a := GetHandler("modes-a")
+	err := LoadConfig(conf)
+	if _, ok := err.(*DuplicateNameError); !ok {
+		t.Fatalf("strict mode should fail with DuplicateNameError, got %v", err)
+	}
+	if GetHandler("modes-a") != a {
+		t.Fatalf("failed strict load should keep the registered handler")
+	}
+
+	if err := LoadConfig([]byte(`{"handlers": {"modes-b": {"writer": "stderr"}}}`), WithConfigMode(MergeMode)); err != nil {
+		t.Fatal(err)
+	}
+	if GetHandler("modes-a") != a || GetHandler("modes-b") == nil {
+		t.Fatalf("merge mode should keep modes-a and add modes-b")
+	}
+
+	if err := LoadConfig(conf, WithConfigMode(ReplaceMode)); err != nil {
+		t.Fatal(err)
+	}
+	if GetHandler("modes-a") == a || GetHandler("modes-b") != nil {
+		t.Fatalf("replace mode should replace modes-a and remove modes-b")
+	}
+	// added a, added b, removed a and b, added a again
+	if len(changed) != 5 {
+		t.Errorf("listener got %v", changed)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	conf := []byte(`{
+		"formatters": {"unused": {"fmt": "${msg}"}},
+		"handlers": {
+			"file": {"level": 10, "filters": ["missing"]},
+			"stream": {"writer": "stdin", "formatter": "nope"}
+		},
+		"loggers": {"validate": {"handlers": ["file", "none"], "async": "many"}}
+	}`)
+	expected := []string{
+		"formatters.unused: defined but not used",
+		"handlers.file.level: expected string",
+		"handlers.file.filters[0]: unknown filter name: missing",
+		"handlers.stream.writer: unknown writer: stdin",
+		"handlers.stream.formatter: unknown formatter name: nope",
+		"loggers.validate.handlers[1]: unknown handler name: none",
+		"loggers.validate.async: expected number",
+	}
+	errs := ValidateConfig(conf)
+	got := make(map[string]bool)
+	for _, err := range errs {
+		got[err.Error()] = true
+	}
+	for _, msg := range expected {
+		if !got[msg] {
+			t.Errorf("missing error %q in %v", msg, errs)
+		}
+	}
+
+	err := LoadConfig(conf)
+	if _, ok := err.(ConfigErrors); !ok {
+		t.Fatalf("LoadConfig should return ConfigErrors, got %v", err)
+	}
+	if GetHandler("file") != nil || GetLogger("validate").handlerList() != nil {
+		t.Errorf("invalid config should not be loaded")
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	restoreRegisters(t)
+	yamlConf := []byte(`
+handlers:
+  yaml-handler:
+    writer: stderr
+    level: INFO
+loggers:
+  yaml-logger:
+    level: WARNING
+    handlers: [yaml-handler]
+    async: 8
+`)
+	tomlConf := []byte(`
+[handlers.toml-handler]
+writer = "stderr"
+level = "INFO"
+
+[loggers.toml-logger]
+level = "WARNING"
+handlers = ["toml-handler"]
+async = 8
+`)
+	if err := LoadConfigYAML(yamlConf); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigTOML(tomlConf); err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"yaml", "toml"} {
+		lg := GetLogger(prefix + "-logger")
+		if lg.Level() != WarnLevel || lg.asyncState() == nil {
+			t.Errorf("%s: logger not configured", prefix)
+		}
+		if h := GetHandler(prefix + "-handler"); h == nil || lg.handlerList()[0] != h {
+			t.Errorf("%s: handler not configured", prefix)
+		}
+		lg.Close()
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	env := map[string]string{
+		"TEST_LOG_FILE":                     filepath.Join(os.TempDir(), "glogger-env.log"),
+		"GLOGGER_LOGGERS_ENV_LOGGER_LEVEL":  "ERROR",
+		"GLOGGER_HANDLERS_ENV_FILE_LEVEL":   "WARNING",
+		"GLOGGER_LOGGERS_ENV_LOGGER_ASYNC":  "16",
+		"GLOGGER_LOGGERS_MISSING_PROPAGATE": "false",
+		"GLOGGER_HANDLERS_ENV_FILE_SECRET":  "123456",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	conf := []byte(`{
+		"formatters": {"env-fmt": {"fmt": "${msg} $${HOME} ${UNSET_VAR:-none}"}},
+		"handlers": {"env-file": {"filename": "${TEST_LOG_FILE}", "formatter": "env-fmt", "level": "DEBUG", "secret": "none"}},
+		"loggers": {"env-logger": {"level": "INFO", "handlers": ["env-file"]}}
+	}`)
+	cm, errs := checkConfig(conf, newConfigOptions(nil))
+	if len(errs) != 1 || errs[0].(*ConfigError).Warning != true {
+		t.Fatalf("expect a warning of the unmatched override, got %v", errs)
+	}
+	expected := map[string]interface{}{
+		"formatters.env-fmt.fmt":     "${msg} ${HOME} none",
+		"handlers.env-file.filename": env["TEST_LOG_FILE"],
+		"handlers.env-file.level":    "WARNING",
+		"handlers.env-file.secret":   "123456",
+		"loggers.env-logger.level":   "ERROR",
+		"loggers.env-logger.async":   float64(16),
+	}
+	for path, value := range expected {
+		parts := strings.SplitN(path, ".", 3)
+		if v := cm[parts[0]][parts[1]][parts[2]]; v != value {
+			t.Errorf("%s: expect %v, got %v", path, value, v)
+		}
+	}
+
+	cm, _ = checkConfig(conf, newConfigOptions([]ConfigOption{WithoutEnv()}))
+	if v := cm["loggers"]["env-logger"]["level"]; v != "INFO" {
+		t.Errorf("WithoutEnv should keep the level, got %v", v)
+	}
+	if errs := ValidateConfig([]byte(`{"handlers": {"h": {"writer": "${UNSET_VAR}"}}}`)); len(errs) == 0 {
+		t.Errorf("unset variable without default should be reported")
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	restoreRegisters(t)
+	propagate := false
+	cfg := &Config{
+		Formatters: map[string]ComponentConfig{
+			"struct-fmt": {Options: map[string]interface{}{"fmt": "${levelname} ${msg}"}},
+		},
+		Handlers: map[string]ComponentConfig{
+			"struct-handler": {
+				Level:     "INFO",
+				Formatter: "struct-fmt",
+				Options:   map[string]interface{}{"writer": "stderr"},
+			},
+		},
+		Loggers: map[string]LoggerConfig{
+			"struct-logger": {Level: "WARNING", Propagate: &propagate, Handlers: []string{"struct-handler"}},
+		},
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if h := decoded.Handlers["struct-handler"]; h.Level != "INFO" || h.Options["writer"] != "stderr" {
+		t.Errorf("unexpected decoded handler %+v from %s", h, data)
+	}
+	if err := ApplyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	current := CurrentConfig()
+	h := current.Handlers["struct-handler"]
+	if h.Builder != "github.com/Xuyuanp/glogger.StreamHandler" || h.Level != "INFO" ||
+		h.Formatter != "struct-fmt" || h.Options["writer"] != "stderr" {
+		t.Errorf("unexpected exported handler %+v", h)
+	}
+	if f := current.Formatters["struct-fmt"]; f.Options["fmt"] != "${levelname} ${msg}" {
+		t.Errorf("unexpected exported formatter %+v", f)
+	}
+	lg := current.Loggers["struct-logger"]
+	if lg.Level != "WARNING" || *lg.Propagate || len(lg.Handlers) != 1 || lg.Handlers[0] != "struct-handler" {
+		t.Errorf("unexpected exported logger %+v", lg)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ContextExtractor extracts fields from a context, like trace id stored by a tracing library.
+type ContextExtractor func(ctx context.Context) Fields
+
+var contextExtractorRegister = NewRegister()
+
+var (
+	contextExtractorsMu sync.Mutex
+	contextExtractors   atomic.Value // []ContextExtractor in order of names, rebuilt when the register changes
+)
+
+// RegisterContextExtractor register a ContextExtractor with name.
+// Fields extracted by all the registered ContextExtractors are attached to the records
+// logged with a context, in order of their names.
+func RegisterContextExtractor(name string, extractor ContextExtractor) {
+	contextExtractorRegister.Register(name, extractor)
+}
+
+// UnregisterContextExtractor unregister the ContextExtractor with name, and return it or nil.
+func UnregisterContextExtractor(name string) ContextExtractor {
+	if v := contextExtractorRegister.Unregister(name); v != nil {
+		return v.(ContextExtractor)
+	}
+	return nil
+}
+
+// ContextExtractorNames return the names of all the registered ContextExtractors in order
+func ContextExtractorNames() []string {
+	return contextExtractorRegister.Names()
+}
+
+// updateContextExtractors rebuilds the snapshot of the registered ContextExtractors
+func updateContextExtractors(string, interface{}, interface{}) {
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+	var extractors []ContextExtractor
+	contextExtractorRegister.Range(func(_ string, v interface{}) bool {
+		extractors = append(extractors, v.(ContextExtractor))
+		return true
+	})
+	contextExtractors.Store(extractors)
+}
+
+// contextFields return the fields extracted from ctx by the registered ContextExtractors
+func contextFields(ctx context.Context) Fields {
+	extractors, _ := contextExtractors.Load().([]ContextExtractor)
+	var fields Fields
+	for _, extractor := range extractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+	return fields
+}
+
+type contextFieldsKey struct{}
+
+// ContextWithFields return a copy of ctx carrying the key/value pairs as well as the ones carried by ctx.
+// They are extracted by the builtin ContextExtractor "fields".
+func ContextWithFields(ctx context.Context, kv ...interface{}) context.Context {
+	return context.WithValue(ctx, contextFieldsKey{}, FieldsFromContext(ctx).With(kv...))
+}
+
+// FieldsFromContext return the fields carried by ctx, added by ContextWithFields
+func FieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(contextFieldsKey{}).(Fields)
+	return fields
+}
+
+func init() {
+	contextExtractorRegister.AddListener(updateContextExtractors)
+	RegisterContextExtractor("fields", FieldsFromContext)
+}
+
+// DebugContext logs in debug level with the fields extracted from ctx
+func (l *Logger) DebugContext(ctx context.Context, f string, v ...interface{}) {
+	if l.Enabled(DebugLevel) {
+		l.log(ctx, DebugLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
+
+// InfoContext logs in info level with the fields extracted from ctx
+func (l *Logger) InfoContext(ctx context.Context, f string, v ...interface{}) {
+	if l.Enabled(InfoLevel) {
+		l.log(ctx, InfoLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
+
+// WarningContext logs in warning level with the fields extracted from ctx
+func (l *Logger) WarningContext(ctx context.Context, f string, v ...interface{}) {
+	if l.Enabled(WarnLevel) {
+		l.log(ctx, WarnLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
+
+// ErrorContext logs in error level with the fields extracted from ctx
+func (l *Logger) ErrorContext(ctx context.Context, f string, v ...interface{}) {
+	if l.Enabled(ErrorLevel) {
+		l.log(ctx, ErrorLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
+
+// CriticalContext logs in critical level with the fields extracted from ctx
+func (l *Logger) CriticalContext(ctx context.Context, f string, v ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(ctx, CriticalLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configurations,
+// like GLOGGER_LOGGERS_MAIN_LEVEL=ERROR.
+const EnvPrefix = "GLOGGER_"
+
+// envRegexp matches ${VAR}, ${VAR:-default} and the escaped $${VAR}.
+// Only upper case names are interpolated, so the placeholders of formatters are kept.
+var envRegexp = regexp.MustCompile(`\$(\$?)\{([A-Z_][A-Z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv replaces the environment variables in the string values of config.
+func interpolateEnv(path string, v interface{}) (interface{}, []error) {
+	var errs []error
+	switch v := v.(type) {
+	case string:
+		s := envRegexp.ReplaceAllStringFunc(v, func(match string) string {
+			sub := envRegexp.FindStringSubmatch(match)
+			if sub[1] != "" {
+				return match[1:]
+			}
+			if value, ok := os.LookupEnv(sub[2]); ok && (value != "" || sub[3] == "") {
+				return value
+			}
+			if sub[3] == "" {
+				errs = append(errs, &ConfigError{Path: path, Msg: fmt.Sprintf("environment variable %s is not set", sub[2])})
+			}
+			return sub[4]
+		})
+		return s, errs
+	case map[string]interface{}:
+		for key, value := range v {
+			var es []error
+			v[key], es = interpolateEnv(joinPath(path, key), value)
+			errs = append(errs, es...)
+		}
+	case []interface{}:
+		for i, value := range v {
+			var es []error
+			v[i], es = interpolateEnv(fmt.Sprintf("%s[%d]", path, i), value)
+			errs = append(errs, es...)
+		}
+	}
+	return v, errs
+}
+
+// envName converts a name to the form used in environment variables, "file-handler" to "FILE_HANDLER".
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// envKey finds the key of conf matching the name ignoring case and underscores,
+// or converts the name to camel case, "MAX_SIZE" to "maxSize".
+func envKey(conf map[string]interface{}, name string) string {
+	compact := strings.Replace(name, "_", "", -1)
+	for key := range conf {
+		if strings.Replace(envName(key), "_", "", -1) == compact {
+			return key
+		}
+	}
+	words := strings.Split(strings.ToLower(name), "_")
+	for i := 1; i < len(words); i++ {
+		if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// applyEnvOverrides patches cm with the environment variables GLOGGER_<SECTION>_<NAME>_<KEY>.
+// The values replacing strings are kept as strings, the others are parsed as json,
+// or used as strings if they aren't valid json.
+// Overrides matching no entry of cm are reported as warnings.
+func (cm configMap) applyEnvOverrides() []error {
+	var errs []error
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		kv = kv[len(EnvPrefix):]
+		i := strings.Index(kv, "=")
+		name, value := kv[:i], kv[i+1:]
+		if !cm.applyEnvOverride(name, value) {
+			errs = append(errs, &ConfigError{
+				Msg:     fmt.Sprintf("environment variable %s%s matches no entry", EnvPrefix, name),
+				Warning: true,
+			})
+		}
+	}
+	return errs
+}
+
+func (cm configMap) applyEnvOverride(name, value string) bool {
+	for _, section := range configSections {
+		prefix := envName(section) + "_"
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		// the longest entry name wins, as names may contain underscores
+		var entry string
+		for n := range cm[section] {
+			if strings.HasPrefix(rest, envName(n)+"_") && len(n) > len(entry) {
+				entry = n
+			}
+		}
+		if entry == "" {
+			return false
+		}
+		conf := cm[section][entry]
+		key := envKey(conf, rest[len(envName(entry))+1:])
+		if key == "" {
+			return false
+		}
+		var v interface{} = value
+		if _, ok := conf[key].(string); !ok {
+			if err := json.Unmarshal([]byte(value), &v); err != nil {
+				v = value
+			}
+		}
+		conf[key] = v
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// WarningErr log the err and formatted message in WarnLevel.
+func (l *Logger) WarningErr(err error, f string, v ...interface{}) {
+	if l.Enabled(WarnLevel) {
+		l.log(context.Background(), WarnLevel, fmt.Sprintf(f, v...), nil, err)
+	}
+}
+
+// ErrorErr log the err and formatted message in ErrorLevel.
+func (l *Logger) ErrorErr(err error, f string, v ...interface{}) {
+	if l.Enabled(ErrorLevel) {
+		l.log(context.Background(), ErrorLevel, fmt.Sprintf(f, v...), nil, err)
+	}
+}
+
+// CriticalErr log the err and formatted message in CriticalLevel.
+func (l *Logger) CriticalErr(err error, f string, v ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, fmt.Sprintf(f, v...), nil, err)
+	}
+}
+
+// unwrapErrors return the errors wrapped by err, by Unwrap() error or Unwrap() []error like errors.Join.
+func unwrapErrors(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return []error{inner}
+		}
+	}
+	return nil
+}
+
+// WriteError writes err and the chain of errors it wraps into buf, each wrapped error in a line
+// starting with "caused by: " and indented by its depth. Nothing is written if err is nil.
+func WriteError(buf *bytes.Buffer, err error) {
+	if err == nil {
+		return
+	}
+	buf.WriteString(err.Error())
+	writeCauses(buf, err, 1)
+}
+
+func writeCauses(buf *bytes.Buffer, err error, depth int) {
+	for _, inner := range unwrapErrors(err) {
+		if inner == nil {
+			continue
+		}
+		buf.WriteByte('\n')
+		for i := 0; i < depth; i++ {
+			buf.WriteString("  ")
+		}
+		buf.WriteString("caused by: ")
+		buf.WriteString(inner.Error())
+		writeCauses(buf, inner, depth+1)
+	}
+}
+
+// ErrorChain return err and the chain of errors it wraps in the format of WriteError
+func ErrorChain(err error) string {
+	var buf bytes.Buffer
+	WriteError(&buf, err)
+	return buf.String()
+}
+
+// ErrorStack return the program counters of the stack carried by the deepest error in the chain of err
+// providing one, by a Callers() []uintptr method or a StackTrace() method returning a slice of
+// uintptr like github.com/pkg/errors. It's nil if no error does.
+func ErrorStack(err error) []uintptr {
+	if err == nil {
+		return nil
+	}
+	for _, inner := range unwrapErrors(err) {
+		if pcs := ErrorStack(inner); pcs != nil {
+			return pcs
+		}
+	}
+	return errorCallers(err)
+}
+
+func errorCallers(err error) []uintptr {
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		return e.Callers()
+	}
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	t := m.Type().Out(0)
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	trace := m.Call(nil)[0]
+	if trace.Len() == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return pcs
+}
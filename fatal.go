@@ -0,0 +1,102 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ExitFunc is called by Fatal methods after flushing and closing handlers, it can be replaced in tests.
+var ExitFunc = os.Exit
+
+// FatalFlushTimeout is the longest time Fatal and Panic methods wait for the queued records of async Loggers.
+var FatalFlushTimeout = 5 * time.Second
+
+// Flusher is implemented by handlers which buffer their output
+type Flusher interface {
+	Flush() error
+}
+
+// Fatal logs in critical level, flushes and closes the handlers which records of l are passed to,
+// and then calls ExitFunc(1).
+func (l *Logger) Fatal(f string, v ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
+	l.shutdown(true)
+	ExitFunc(1)
+}
+
+// Fatalw logs msg with key/value pairs like Fatal
+func (l *Logger) Fatalw(msg string, kv ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, msg, MakeFields(kv...), nil)
+	}
+	l.shutdown(true)
+	ExitFunc(1)
+}
+
+// Panic logs in critical level, flushes the handlers which records of l are passed to,
+// and then panics with the message.
+func (l *Logger) Panic(f string, v ...interface{}) {
+	msg := fmt.Sprintf(f, v...)
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, msg, nil, nil)
+	}
+	l.shutdown(false)
+	panic(msg)
+}
+
+// Panicw logs msg with key/value pairs like Panic
+func (l *Logger) Panicw(msg string, kv ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, msg, MakeFields(kv...), nil)
+	}
+	l.shutdown(false)
+	panic(msg)
+}
+
+// shutdown waits for the queued records, and flushes the handlers of l and its ancestors
+// which records propagate to. The handlers are closed as well if close is true.
+func (l *Logger) shutdown(close bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), FatalFlushTimeout)
+	defer cancel()
+	if err := l.Flush(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	for lg := l; lg != nil; lg = lg.Parent() {
+		for _, h := range lg.handlerList() {
+			if f, ok := h.(Flusher); ok {
+				if err := f.Flush(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+			if c, ok := h.(io.Closer); ok && close {
+				if err := c.Close(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+		}
+		if !lg.Propagate() {
+			break
+		}
+	}
+}
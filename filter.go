@@ -16,7 +16,10 @@
 
 package glogger
 
-import "container/list"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 // Filter interface
 type Filter interface {
@@ -31,6 +34,20 @@ func RegisterFilter(name string, filter Filter) {
 	filterRegister.Register(name, filter)
 }
 
+// ReplaceFilter register a Filter with the name whether the name has been registered or not,
+// and return the Filter registered before or nil.
+func ReplaceFilter(name string, filter Filter) Filter {
+	if v := filterRegister.Replace(name, filter); v != nil {
+		return v.(Filter)
+	}
+	return nil
+}
+
+// FilterNames return the names of all the registered Filters in order
+func FilterNames() []string {
+	return filterRegister.Names()
+}
+
 // GetFilter return a Filter regeistered by the name
 func GetFilter(name string) Filter {
 	if v := filterRegister.Get(name); v != nil {
@@ -39,27 +56,54 @@ func GetFilter(name string) Filter {
 	return nil
 }
 
-// GroupFilter struct
+// GroupFilter struct. It's safe for concurrent use, filtering reads a snapshot of
+// the filter list which is replaced on modification.
 type GroupFilter struct {
-	filters *list.List
+	mu      sync.Mutex   // serializes modifications
+	filters atomic.Value // []Filter
 }
 
 // AddFilter add a filter to a filter list
 func (f *GroupFilter) AddFilter(ft Filter) {
-	if f.filters == nil {
-		f.filters = list.New()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old := f.filterList()
+	filters := make([]Filter, 0, len(old)+1)
+	f.filters.Store(append(append(filters, old...), ft))
+}
+
+// SetFilters replaces the filter list
+func (f *GroupFilter) SetFilters(filters ...Filter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.filters.Store(append([]Filter{}, filters...))
+}
+
+// ClearFilters removes all the filters
+func (f *GroupFilter) ClearFilters() {
+	f.SetFilters()
+}
+
+// filterList return the snapshot of filters in order, which mustn't be modified
+func (f *GroupFilter) filterList() []Filter {
+	filters, _ := f.filters.Load().([]Filter)
+	return filters
+}
+
+// needsCaller reports whether any of the filters needs the caller of records
+func (f *GroupFilter) needsCaller() bool {
+	for _, ft := range f.filterList() {
+		if needsCaller(ft) {
+			return true
+		}
 	}
-	f.filters.PushBack(ft)
+	return false
 }
 
 // Filter return true only if all the filters return true, or false if not
 func (f *GroupFilter) Filter(rec *Record) bool {
-	if f.filters == nil {
-		return true
-	}
-	for e := f.filters.Front(); e != nil; e = e.Next() {
-		filter := e.Value.(Filter)
-		if !filter.Filter(rec) {
+	for _, ft := range f.filterList() {
+		if !ft.Filter(rec) {
 			return false
 		}
 	}
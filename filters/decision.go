@@ -0,0 +1,48 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import "github.com/Xuyuanp/glogger"
+
+// maxDecisions is the number of the latest records whose decisions are remembered
+const maxDecisions = 256
+
+// decisionCache remembers the decisions of the latest records, so a filter shared by
+// several handlers decides once per record. It isn't safe for concurrent use.
+type decisionCache struct {
+	decisions map[*glogger.Record]bool
+	order     []*glogger.Record // ring of the records in decisions
+	next      int
+}
+
+func (dc *decisionCache) get(rec *glogger.Record) (pass bool, ok bool) {
+	pass, ok = dc.decisions[rec]
+	return
+}
+
+func (dc *decisionCache) put(rec *glogger.Record, pass bool) {
+	if dc.decisions == nil {
+		dc.decisions = make(map[*glogger.Record]bool, maxDecisions)
+		dc.order = make([]*glogger.Record, maxDecisions)
+	}
+	if old := dc.order[dc.next]; old != nil {
+		delete(dc.decisions, old)
+	}
+	dc.order[dc.next] = rec
+	dc.next = (dc.next + 1) % maxDecisions
+	dc.decisions[rec] = pass
+}
@@ -0,0 +1,194 @@
+package filters
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func record(name string, level glogger.LogLevel, msg string) *glogger.Record {
+	return glogger.NewRecord(name, time.Now(), level, "main.go", "main.main", 1, msg)
+}
+
+func TestBuiltinFilters(t *testing.T) {
+	err := glogger.LoadConfig([]byte(`{
+		"filters": {
+			"test-range": {"builder": "github.com/Xuyuanp/glogger/filters.LevelRangeFilter", "min": "INFO", "max": "ERROR"},
+			"test-min": {"builder": "github.com/Xuyuanp/glogger/filters.LevelRangeFilter", "min": "INFO"},
+			"test-name": {"builder": "github.com/Xuyuanp/glogger/filters.NameFilter", "include": ["app"], "exclude": ["app.db"]},
+			"test-regex": {"builder": "github.com/Xuyuanp/glogger/filters.RegexFilter", "pattern": "^health", "exclude": true}
+		}
+	}`), glogger.WithConfigMode(glogger.MergeMode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		filter string
+		rec    *glogger.Record
+		want   bool
+	}{
+		{"test-range", record("app", glogger.DebugLevel, ""), false},
+		{"test-range", record("app", glogger.WarnLevel, ""), true},
+		{"test-range", record("app", glogger.CriticalLevel, ""), false},
+		{"test-min", record("app", glogger.CriticalLevel+1, ""), true}, // custom level above critical
+		{"test-min", record("app", glogger.DebugLevel, ""), false},
+		{"test-name", record("app.http", glogger.InfoLevel, ""), true},
+		{"test-name", record("application", glogger.InfoLevel, ""), false},
+		{"test-name", record("app.db.pool", glogger.InfoLevel, ""), false},
+		{"test-regex", record("app", glogger.InfoLevel, "healthz ok"), false},
+		{"test-regex", record("app", glogger.InfoLevel, "GET /healthz"), true},
+	}
+	for _, c := range cases {
+		if got := glogger.GetFilter(c.filter).Filter(c.rec); got != c.want {
+			t.Errorf("%s(%s %s %q) = %v, want %v", c.filter, c.rec.Name, c.rec.Level, c.rec.Message, got, c.want)
+		}
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.String()
+}
+
+func TestRateLimitFilterSummary(t *testing.T) {
+	rf := NewRateLimitFilter()
+	rf.Rate = 0.001
+	rf.Summary = 20 * time.Millisecond
+
+	var out syncBuffer
+	h := glogger.NewStreamHandler()
+	h.SetWriter(&out)
+	h.AddFilter(rf)
+	// unregistered, so the summary mustn't be routed by name
+	l := glogger.NewLogger()
+	l.SetHandlers(h)
+
+	for i := 0; i < 5; i++ {
+		l.Error("flapping")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "message repeated 4 times: flapping") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary record: %q", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 2 {
+		t.Errorf("got %d lines, want 2: %q", n, out.String())
+	}
+	for _, name := range glogger.LoggerNames() {
+		if name == "" {
+			t.Error("filters shouldn't create loggers")
+		}
+	}
+}
+
+func TestRateLimitFilterSharedByHandlers(t *testing.T) {
+	rf := NewRateLimitFilter()
+	rf.Rate = 0.001
+	rf.Summary = 20 * time.Millisecond
+
+	outs := make([]syncBuffer, 3)
+	hs := make([]glogger.Handler, len(outs))
+	for i := range outs {
+		h := glogger.NewStreamHandler()
+		h.SetWriter(&outs[i])
+		if i < 2 {
+			h.AddFilter(rf)
+		}
+		hs[i] = h
+	}
+	l := glogger.NewLogger()
+	l.SetHandlers(hs...)
+
+	for i := 0; i < 5; i++ {
+		l.Error("flapping")
+	}
+	for i := 0; i < 2; i++ {
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(outs[i].String(), "message repeated 4 times: flapping") {
+			if time.Now().After(deadline) {
+				t.Fatalf("handler %d got no summary record: %q", i, outs[i].String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if n := strings.Count(outs[i].String(), "\n"); n != 2 {
+			t.Errorf("handler %d got %d lines, want 2: %q", i, n, outs[i].String())
+		}
+	}
+	if got := outs[2].String(); strings.Count(got, "\n") != 5 || strings.Contains(got, "repeated") {
+		t.Errorf("unfiltered handler got %q, want 5 records without summary", got)
+	}
+}
+
+func TestSamplingFilter(t *testing.T) {
+	sf := NewSamplingFilter()
+	if err := sf.LoadConfig(map[string]interface{}{
+		"interval": "1m",
+		"levels": map[string]interface{}{
+			"DEBUG": map[string]interface{}{"first": 2.0, "thereafter": 3.0},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sf.now = func() time.Time { return now }
+
+	var passed []int
+	for i := 1; i <= 10; i++ {
+		if sf.Filter(record("app", glogger.DebugLevel, "")) {
+			passed = append(passed, i)
+		}
+	}
+	if got := fmt.Sprint(passed); got != "[1 2 5 8]" {
+		t.Errorf("passed %s, want [1 2 5 8]", got)
+	}
+	if !sf.Filter(record("app", glogger.InfoLevel, "")) {
+		t.Errorf("level without rule should not be sampled")
+	}
+
+	now = now.Add(time.Minute)
+	if !sf.Filter(record("app", glogger.DebugLevel, "")) {
+		t.Errorf("counter should be reset after interval")
+	}
+}
+
+func TestSamplingFilterSharedByHandlers(t *testing.T) {
+	sf := NewSamplingFilter()
+	sf.Rules[glogger.InfoLevel] = SamplingRule{First: 1}
+
+	outs := make([]syncBuffer, 2)
+	l := glogger.NewLogger()
+	for i := range outs {
+		h := glogger.NewStreamHandler()
+		h.SetWriter(&outs[i])
+		h.AddFilter(sf)
+		l.AddHandler(h)
+	}
+	for i := 0; i < 3; i++ {
+		l.Info("sampled")
+	}
+	for i := range outs {
+		if n := strings.Count(outs[i].String(), "\n"); n != 1 {
+			t.Errorf("handler %d got %d lines, want 1: %q", i, n, outs[i].String())
+		}
+	}
+}
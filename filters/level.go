@@ -0,0 +1,87 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import (
+	"math"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/filters.LevelRangeFilter", func() glogger.ConfigLoader {
+		return NewLevelRangeFilter()
+	})
+}
+
+// Bounds of LevelRangeFilter which aren't set, so custom levels registered by RegisterLevel pass
+const (
+	unboundedMin glogger.LogLevel = math.MinInt8
+	unboundedMax glogger.LogLevel = math.MaxInt8
+)
+
+// LevelRangeFilter passes records whose level is between Min and Max, both inclusive
+type LevelRangeFilter struct {
+	Min glogger.LogLevel
+	Max glogger.LogLevel
+}
+
+// NewLevelRangeFilter return a new LevelRangeFilter which passes all the levels, including custom ones
+func NewLevelRangeFilter() *LevelRangeFilter {
+	lf := &LevelRangeFilter{
+		Min: unboundedMin,
+		Max: unboundedMax,
+	}
+	return lf
+}
+
+// NeedsCaller return false, only the level is used
+func (lf *LevelRangeFilter) NeedsCaller() bool {
+	return false
+}
+
+// Filter return true if the level of record is in range
+func (lf *LevelRangeFilter) Filter(rec *glogger.Record) bool {
+	return rec.Level >= lf.Min && rec.Level <= lf.Max
+}
+
+// LoadConfig load configuration from a map
+func (lf *LevelRangeFilter) LoadConfig(config map[string]interface{}) error {
+	c := glogger.NewConfigChecker("", config)
+	if level, ok := c.Level("min", false); ok {
+		lf.Min = level
+	}
+	if level, ok := c.Level("max", false); ok {
+		lf.Max = level
+	}
+	if lf.Min > lf.Max {
+		c.Errorf("min", "min level %s is above max level %s", lf.Min, lf.Max)
+	}
+	return c.Err()
+}
+
+// ExportConfig exports the level names of the bounds which are set
+func (lf *LevelRangeFilter) ExportConfig() map[string]interface{} {
+	m := make(map[string]interface{})
+	if lf.Min != unboundedMin {
+		m["min"] = glogger.LevelName(lf.Min)
+	}
+	if lf.Max != unboundedMax {
+		m["max"] = glogger.LevelName(lf.Max)
+	}
+	return m
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/filters.NameFilter", func() glogger.ConfigLoader {
+		return NewNameFilter()
+	})
+}
+
+// NameFilter passes records by logger name. A name matches a prefix if it's the prefix
+// itself or one of its descendants, e.g. "app.db" matches both "app.db" and "app.db.pool".
+type NameFilter struct {
+	Include []string `json:"include"` // pass only records matching one of them, all if empty
+	Exclude []string `json:"exclude"` // drop records matching one of them
+}
+
+// NewNameFilter return a new NameFilter
+func NewNameFilter() *NameFilter {
+	return &NameFilter{}
+}
+
+// NeedsCaller return false, only the logger name is used
+func (nf *NameFilter) NeedsCaller() bool {
+	return false
+}
+
+// Filter return true if the logger name is included and not excluded
+func (nf *NameFilter) Filter(rec *glogger.Record) bool {
+	if len(nf.Include) > 0 && !matchAny(rec.Name, nf.Include) {
+		return false
+	}
+	return !matchAny(rec.Name, nf.Exclude)
+}
+
+func matchAny(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig load configuration from a map
+func (nf *NameFilter) LoadConfig(config map[string]interface{}) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, nf)
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/filters.RateLimitFilter", func() glogger.ConfigLoader {
+		return NewRateLimitFilter()
+	})
+}
+
+// RateLimitKeys maps key names to functions which return the rate limit key of a record
+var RateLimitKeys = map[string]func(rec *glogger.Record) string{
+	"caller": func(rec *glogger.Record) string {
+		return rec.LFile + ":" + strconv.Itoa(rec.Line)
+	},
+	"message": func(rec *glogger.Record) string {
+		return rec.Message
+	},
+	"name": func(rec *glogger.Record) string {
+		return rec.Name
+	},
+}
+
+// maxIdleBuckets is the number of buckets above which idle ones are released
+const maxIdleBuckets = 4096
+
+// RateLimitFilter limits records with a token bucket per key. Records over the limit are dropped,
+// and if Summary is positive, a "message repeated N times" record is emitted for them every Summary
+// through the handlers which dropped them by the filter, or the handlers of the Logger which logged them
+// if the filter is one of the Logger. An instance shared by several handlers decides once per record.
+type RateLimitFilter struct {
+	Key     func(rec *glogger.Record) string
+	Rate    float64 // records per second
+	Burst   int
+	Summary time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	summaries map[*glogger.Record]bool
+	decisions decisionCache
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+	rec        glogger.Record    // a copy of the last suppressed record, which is still being handled
+	handlers   []glogger.Handler // the handlers which dropped the records
+	timer      *time.Timer
+}
+
+// addHandler remembers the handler applying the filter to rec, if any
+func (b *bucket) addHandler(rec *glogger.Record) {
+	h := rec.Handler()
+	if h == nil {
+		return
+	}
+	for _, bh := range b.handlers {
+		if bh == h {
+			return
+		}
+	}
+	b.handlers = append(b.handlers, h)
+}
+
+// NewRateLimitFilter return a new RateLimitFilter keyed by call site, allowing one record per second
+func NewRateLimitFilter() *RateLimitFilter {
+	rf := &RateLimitFilter{
+		Key:       RateLimitKeys["caller"],
+		Rate:      1,
+		Burst:     1,
+		buckets:   make(map[string]*bucket),
+		summaries: make(map[*glogger.Record]bool),
+		now:       time.Now,
+	}
+	return rf
+}
+
+// keyName return the name of Key in RateLimitKeys, or "" if it isn't one of them
+func (rf *RateLimitFilter) keyName() string {
+	key := reflect.ValueOf(rf.Key).Pointer()
+	for name, fn := range RateLimitKeys {
+		if reflect.ValueOf(fn).Pointer() == key {
+			return name
+		}
+	}
+	return ""
+}
+
+// NeedsCaller reports whether Key is the caller key, or one not in RateLimitKeys
+func (rf *RateLimitFilter) NeedsCaller() bool {
+	name := rf.keyName()
+	return name == "caller" || name == ""
+}
+
+// Filter return false if the record exceeds the rate limit of its key
+func (rf *RateLimitFilter) Filter(rec *glogger.Record) bool {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.summaries[rec] {
+		return true
+	}
+	if pass, ok := rf.decisions.get(rec); ok {
+		if b := rf.buckets[rf.Key(rec)]; !pass && b != nil {
+			b.addHandler(rec)
+		}
+		return pass
+	}
+	now := rf.now()
+	key := rf.Key(rec)
+	b, ok := rf.buckets[key]
+	if !ok {
+		if len(rf.buckets) >= maxIdleBuckets {
+			rf.releaseIdle(now)
+		}
+		b = &bucket{tokens: float64(rf.Burst), last: now}
+		rf.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * rf.Rate
+	if b.tokens > float64(rf.Burst) {
+		b.tokens = float64(rf.Burst)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		rf.decisions.put(rec, true)
+		return true
+	}
+	b.suppressed++
+	b.rec = *rec
+	b.addHandler(rec)
+	if rf.Summary > 0 && b.timer == nil {
+		b.timer = time.AfterFunc(rf.Summary, func() { rf.summarize(key) })
+	}
+	rf.decisions.put(rec, false)
+	return false
+}
+
+// releaseIdle deletes buckets which are full again and have nothing to summarize
+func (rf *RateLimitFilter) releaseIdle(now time.Time) {
+	for key, b := range rf.buckets {
+		full := b.tokens+now.Sub(b.last).Seconds()*rf.Rate >= float64(rf.Burst)
+		if full && b.timer == nil {
+			delete(rf.buckets, key)
+		}
+	}
+}
+
+func (rf *RateLimitFilter) summarize(key string) {
+	rf.mu.Lock()
+	b := rf.buckets[key]
+	b.timer = nil
+	if b.suppressed == 0 {
+		rf.mu.Unlock()
+		return
+	}
+	summary := b.rec
+	summary.Time = rf.now()
+	summary.Message = fmt.Sprintf("message repeated %d times: %s", b.suppressed, b.rec.Message)
+	summary.Fields = b.rec.Fields.With("repeated", b.suppressed)
+	handlers := b.handlers
+	b.suppressed = 0
+	b.rec = glogger.Record{}
+	b.handlers = nil
+	rf.summaries[&summary] = true
+	rf.mu.Unlock()
+
+	if len(handlers) == 0 {
+		if l := summary.Logger(); l != nil {
+			l.Handle(&summary)
+		}
+	}
+	for _, h := range handlers {
+		if summary.Level >= h.Level() && h.Filter(&summary) {
+			h.Handle(&summary)
+		}
+	}
+	rf.mu.Lock()
+	delete(rf.summaries, &summary)
+	rf.mu.Unlock()
+}
+
+// LoadConfig load configuration from a map
+func (rf *RateLimitFilter) LoadConfig(config map[string]interface{}) error {
+	c := glogger.NewConfigChecker("", config)
+	if key, ok := c.String("key", false); ok {
+		if fn, ok := RateLimitKeys[key]; ok {
+			rf.Key = fn
+		} else {
+			c.Errorf("key", "unknown rate limit key: %s", key)
+		}
+	}
+	if rate, ok := c.Float("rate", true); ok {
+		rf.Rate = rate
+		if rate <= 0 {
+			c.Errorf("rate", "expected positive number")
+		}
+	}
+	if burst, ok := c.Int("burst", false); ok {
+		rf.Burst = burst
+		if burst < 1 {
+			c.Errorf("burst", "expected positive integer")
+		}
+	} else if rf.Burst = int(rf.Rate); rf.Burst < 1 {
+		rf.Burst = 1
+	}
+	if summary, ok := c.Duration("summary", false); ok {
+		rf.Summary = summary
+	}
+	return c.Err()
+}
+
+// ExportConfig exports the name of Key if it's one of RateLimitKeys
+func (rf *RateLimitFilter) ExportConfig() map[string]interface{} {
+	m := map[string]interface{}{
+		"rate":  rf.Rate,
+		"burst": rf.Burst,
+	}
+	if rf.Summary > 0 {
+		m["summary"] = rf.Summary.String()
+	}
+	if name := rf.keyName(); name != "" {
+		m["key"] = name
+	}
+	return m
+}
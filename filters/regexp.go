@@ -0,0 +1,80 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import (
+	"regexp"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/filters.RegexFilter", func() glogger.ConfigLoader {
+		return NewRegexFilter()
+	})
+}
+
+// RegexFilter passes records whose message matches Regexp, or drops them if Exclude is true
+type RegexFilter struct {
+	Regexp  *regexp.Regexp
+	Exclude bool
+}
+
+// NewRegexFilter return a new RegexFilter which passes all the records
+func NewRegexFilter() *RegexFilter {
+	return &RegexFilter{}
+}
+
+// NeedsCaller return false, only the message is used
+func (rf *RegexFilter) NeedsCaller() bool {
+	return false
+}
+
+// Filter return true if the message matches, or doesn't if Exclude is true
+func (rf *RegexFilter) Filter(rec *glogger.Record) bool {
+	if rf.Regexp == nil {
+		return true
+	}
+	return rf.Regexp.MatchString(rec.Message) != rf.Exclude
+}
+
+// LoadConfig load configuration from a map
+func (rf *RegexFilter) LoadConfig(config map[string]interface{}) error {
+	c := glogger.NewConfigChecker("", config)
+	if pattern, ok := c.String("pattern", true); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			c.Errorf("pattern", "%s", err)
+		}
+		rf.Regexp = re
+	}
+	if exclude, ok := c.Bool("exclude", false); ok {
+		rf.Exclude = exclude
+	}
+	return c.Err()
+}
+
+// ExportConfig exports the pattern
+func (rf *RegexFilter) ExportConfig() map[string]interface{} {
+	m := map[string]interface{}{
+		"exclude": rf.Exclude,
+	}
+	if rf.Regexp != nil {
+		m["pattern"] = rf.Regexp.String()
+	}
+	return m
+}
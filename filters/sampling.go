@@ -0,0 +1,172 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filters
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/filters.SamplingFilter", func() glogger.ConfigLoader {
+		return NewSamplingFilter()
+	})
+}
+
+// SamplingRule passes the First records per interval, and every Thereafter-th record afterwards.
+// Thereafter 0 drops all the records after the First ones.
+type SamplingRule struct {
+	First      int
+	Thereafter int
+}
+
+// SamplingFilter samples records per call site and level. Levels without a rule aren't sampled.
+// An instance shared by several handlers counts each record once.
+type SamplingFilter struct {
+	Interval time.Duration
+	Rules    map[glogger.LogLevel]SamplingRule
+
+	mu        sync.Mutex
+	counters  map[samplingKey]*samplingCounter
+	decisions decisionCache
+	now       func() time.Time
+}
+
+type samplingKey struct {
+	file  string
+	line  int
+	level glogger.LogLevel
+}
+
+type samplingCounter struct {
+	resetAt time.Time
+	n       int
+}
+
+// NewSamplingFilter return a new SamplingFilter with one second interval and no rule
+func NewSamplingFilter() *SamplingFilter {
+	sf := &SamplingFilter{
+		Interval: time.Second,
+		Rules:    make(map[glogger.LogLevel]SamplingRule),
+		counters: make(map[samplingKey]*samplingCounter),
+		now:      time.Now,
+	}
+	return sf
+}
+
+// NeedsCaller return true, records are sampled per call site
+func (sf *SamplingFilter) NeedsCaller() bool {
+	return true
+}
+
+// Filter return true if the record is sampled
+func (sf *SamplingFilter) Filter(rec *glogger.Record) bool {
+	rule, ok := sf.Rules[rec.Level]
+	if !ok {
+		return true
+	}
+	key := samplingKey{file: rec.LFile, line: rec.Line, level: rec.Level}
+	now := sf.now()
+
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if pass, ok := sf.decisions.get(rec); ok {
+		return pass
+	}
+	c, ok := sf.counters[key]
+	if !ok {
+		c = &samplingCounter{}
+		sf.counters[key] = c
+	}
+	if !now.Before(c.resetAt) {
+		c.n = 0
+		c.resetAt = now.Add(sf.Interval)
+	}
+	c.n++
+	pass := c.n <= rule.First || rule.Thereafter > 0 && (c.n-rule.First)%rule.Thereafter == 0
+	sf.decisions.put(rec, pass)
+	return pass
+}
+
+func parseSamplingRule(c *glogger.ConfigChecker, rule *SamplingRule) {
+	for key, field := range map[string]*int{"first": &rule.First, "thereafter": &rule.Thereafter} {
+		if n, ok := c.Int(key, false); ok {
+			if n < 0 {
+				c.Errorf(key, "expected non-negative integer")
+			}
+			*field = n
+		}
+	}
+}
+
+// LoadConfig load configuration from a map
+func (sf *SamplingFilter) LoadConfig(config map[string]interface{}) error {
+	c := glogger.NewConfigChecker("", config)
+	if interval, ok := c.Duration("interval", false); ok {
+		sf.Interval = interval
+	}
+	// top level rule applies to all the levels
+	_, hasFirst := config["first"]
+	_, hasThereafter := config["thereafter"]
+	if hasFirst || hasThereafter {
+		var rule SamplingRule
+		parseSamplingRule(c, &rule)
+		for _, level := range glogger.Levels() {
+			sf.Rules[level] = rule
+		}
+	}
+	if levels, ok := c.Map("levels", false); ok {
+		for name := range levels {
+			level, err := glogger.ParseLevel(name)
+			if err != nil {
+				c.Errorf("levels", "%s", err)
+				continue
+			}
+			conf, ok := levels[name].(map[string]interface{})
+			if !ok {
+				c.Errorf("levels."+name, "expected object")
+				continue
+			}
+			lc := glogger.NewConfigChecker("levels."+name, conf)
+			rule := sf.Rules[level]
+			parseSamplingRule(lc, &rule)
+			sf.Rules[level] = rule
+			c.Add(lc.Errors()...)
+		}
+	}
+	return c.Err()
+}
+
+// ExportConfig exports the rules by level names
+func (sf *SamplingFilter) ExportConfig() map[string]interface{} {
+	levels := make(map[string]interface{}, len(sf.Rules))
+	for level, rule := range sf.Rules {
+		levels[glogger.LevelName(level)] = map[string]interface{}{
+			"first":      rule.First,
+			"thereafter": rule.Thereafter,
+		}
+	}
+	m := map[string]interface{}{
+		"levels": levels,
+	}
+	if sf.Interval > 0 {
+		m["interval"] = sf.Interval.String()
+	}
+	return m
+}
@@ -17,9 +17,14 @@
 package glogger
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Formatter interface
@@ -35,6 +40,20 @@ func RegisterFormatter(name string, formatter Formatter) {
 	formatterRegister.Register(name, formatter)
 }
 
+// ReplaceFormatter register a Formatter with the name whether the name has been registered or not,
+// and return the Formatter registered before or nil.
+func ReplaceFormatter(name string, formatter Formatter) Formatter {
+	if v := formatterRegister.Replace(name, formatter); v != nil {
+		return v.(Formatter)
+	}
+	return nil
+}
+
+// FormatterNames return the names of all the registered Formatters in order
+func FormatterNames() []string {
+	return formatterRegister.Names()
+}
+
 // GetFormatter return formater regeistered by the name
 func GetFormatter(name string) Formatter {
 	if v := formatterRegister.Get(name); v != nil {
@@ -49,10 +68,12 @@ func init() {
 	})
 }
 
-// DefaultFormatter struct
+// DefaultFormatter struct. Fmt can be set directly before the formatter is used,
+// and only by SetFmt afterwards.
 type DefaultFormatter struct {
-	TimeFmt string `json:timefmt`
-	Fmt     string `json:fmt`
+	TimeFmt string       `json:"timefmt"`
+	Fmt     string       `json:"fmt"`
+	tmpl    atomic.Value // *Template compiled from Fmt
 }
 
 // DefaultFormat is default format of log message
@@ -70,47 +91,134 @@ func NewDefaultFormatter() *DefaultFormatter {
 	return df
 }
 
-// FieldHolderRegexp
-var FieldHolderRegexp = regexp.MustCompile(`\$\{\w+\}`)
+// FieldHolderRegexp matches placeholders like ${name} or ${field:key}.
+// Formatters don't use it any more, see Template.
+var FieldHolderRegexp = regexp.MustCompile(`\$\{\w+(?::[^}]+)?\}`)
+
+// PlaceholderFunc writes the value of the placeholder name for rec into buf,
+// it returns false if the placeholder is unknown.
+type PlaceholderFunc func(buf *bytes.Buffer, rec *Record, name string) bool
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// SetFmt set the format and compile it
+func (df *DefaultFormatter) SetFmt(format string) {
+	df.Fmt = format
+	df.tmpl.Store(CompileTemplate(format))
+}
+
+// Template return the compiled Fmt. Fmt is compiled on the first use unless SetFmt is called,
+// changing it directly afterwards has no effect.
+func (df *DefaultFormatter) Template() *Template {
+	if t, ok := df.tmpl.Load().(*Template); ok {
+		return t
+	}
+	t := CompileTemplate(df.Fmt)
+	df.tmpl.Store(t)
+	return t
+}
+
+// callerPlaceholders are the placeholders using the caller of records
+var callerPlaceholders = map[string]bool{
+	"lfile": true,
+	"sfile": true,
+	"func":  true,
+	"line":  true,
+}
+
+// NeedsCaller reports whether Fmt has any of the placeholders lfile, sfile, func and line
+func (df *DefaultFormatter) NeedsCaller() bool {
+	for _, seg := range df.Template().segments {
+		if seg.placeholder && callerPlaceholders[seg.text] {
+			return true
+		}
+	}
+	return false
+}
 
 // Format formats a record to string
 func (df *DefaultFormatter) Format(rec *Record) string {
-	return FieldHolderRegexp.ReplaceAllStringFunc(df.Fmt, func(match string) string {
-		fieldName := match[2 : len(match)-1]
-		switch fieldName {
-		case "name":
-			return rec.Name
-		case "time":
-			return rec.Time.Format(df.TimeFmt)
-		case "levelno":
-			return fmt.Sprintf("%d", rec.Level)
-		case "levelname":
-			return rec.Level.String()
-		case "lfile":
-			return rec.LFile
-		case "sfile":
-			return rec.SFile
-		case "func":
-			return rec.Func
-		case "line":
-			return fmt.Sprintf("%d", rec.Line)
-		case "msg":
-			return rec.Message
+	return df.FormatWith(rec, nil)
+}
+
+// FormatWith formats a record to string, placeholders provided by fn take precedence.
+func (df *DefaultFormatter) FormatWith(rec *Record, fn PlaceholderFunc) string {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	df.Template().Execute(buf, func(buf *bytes.Buffer, name string) bool {
+		if fn != nil && fn(buf, rec, name) {
+			return true
 		}
-		return match
+		return df.WritePlaceholder(buf, rec, name)
 	})
+	s := buf.String()
+	bufferPool.Put(buf)
+	return s
+}
+
+// WritePlaceholder writes the value of the placeholder name for rec into buf,
+// it returns false if the placeholder is unknown.
+func (df *DefaultFormatter) WritePlaceholder(buf *bytes.Buffer, rec *Record, name string) bool {
+	var scratch [64]byte
+	switch name {
+	case "name":
+		buf.WriteString(rec.Name)
+	case "time":
+		buf.Write(rec.Time.AppendFormat(scratch[:0], df.TimeFmt))
+	case "levelno":
+		buf.Write(strconv.AppendInt(scratch[:0], int64(rec.Level), 10))
+	case "levelname":
+		buf.WriteString(rec.Level.String())
+	case "lfile":
+		buf.WriteString(rec.LFile)
+	case "sfile":
+		buf.WriteString(rec.SFile)
+	case "func":
+		buf.WriteString(rec.Func)
+	case "line":
+		buf.Write(strconv.AppendInt(scratch[:0], int64(rec.Line), 10))
+	case "msg":
+		buf.WriteString(rec.Message)
+	case "stack":
+		WriteStack(buf, rec, "", "", "")
+	case "error":
+		WriteError(buf, rec.Err)
+	case "fields":
+		for i, f := range rec.Fields {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(f.Key)
+			buf.WriteByte('=')
+			fmt.Fprint(buf, f.Value)
+		}
+	default:
+		if !strings.HasPrefix(name, "field:") {
+			return false
+		}
+		if v, ok := rec.Fields.Get(name[len("field:"):]); ok {
+			fmt.Fprint(buf, v)
+		}
+	}
+	return true
 }
 
 // LoadConfigJSON load configuration from json
 func (df *DefaultFormatter) LoadConfigJSON(config []byte) error {
-	return json.Unmarshal(config, df)
+	if err := json.Unmarshal(config, df); err != nil {
+		return err
+	}
+	df.SetFmt(df.Fmt)
+	return nil
 }
 
 // LoadConfig load configuration from a map
 func (df *DefaultFormatter) LoadConfig(config map[string]interface{}) error {
-	var err error
-	if code, err := json.Marshal(config); err == nil {
-		return df.LoadConfigJSON(code)
+	code, err := json.Marshal(config)
+	if err != nil {
+		return err
 	}
-	return err
+	return df.LoadConfigJSON(code)
 }
@@ -1,6 +1,10 @@
 package glogger
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+	"time"
+)
 
 func benchmarkTask(b *testing.B, fn func(int)) {
 	b.ReportAllocs()
@@ -10,11 +14,51 @@ func benchmarkTask(b *testing.B, fn func(int)) {
 	}
 }
 
+func benchmarkRecord() *Record {
+	return NewRecord("main", time.Now(), InfoLevel, "/go/src/app/main.go", "main.main", 42, "hello world")
+}
+
 func BenchmarkFormat(b *testing.B) {
 	formatter := NewDefaultFormatter()
-	rec := &Record{}
+	rec := benchmarkRecord()
 
 	benchmarkTask(b, func(i int) {
 		formatter.Format(rec)
 	})
 }
+
+// BenchmarkFormatRegexp formats like DefaultFormatter did before Template, for comparison.
+func BenchmarkFormatRegexp(b *testing.B) {
+	formatter := NewDefaultFormatter()
+	rec := benchmarkRecord()
+
+	benchmarkTask(b, func(i int) {
+		FieldHolderRegexp.ReplaceAllStringFunc(formatter.Fmt, func(match string) string {
+			switch match[2 : len(match)-1] {
+			case "time":
+				return rec.Time.Format(formatter.TimeFmt)
+			case "levelname":
+				return rec.Level.String()
+			case "sfile":
+				return rec.SFile
+			case "line":
+				return fmt.Sprintf("%d", rec.Line)
+			case "msg":
+				return rec.Message
+			}
+			return match
+		})
+	})
+}
+
+func TestFormatTemplate(t *testing.T) {
+	formatter := NewDefaultFormatter()
+	formatter.Fmt = "${name} ${unknown} ${ ${field:id}$${msg}"
+	rec := benchmarkRecord()
+	rec.Message = "${name} ${msg}"
+	rec.Fields = MakeFields("id", 7)
+
+	if got, want := formatter.Format(rec), "main ${unknown} ${ 7$${name} ${msg}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
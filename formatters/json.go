@@ -0,0 +1,162 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/formatters.JSONFormatter", func() glogger.ConfigLoader {
+		return NewJSONFormatter()
+	})
+}
+
+// RecordKeys holds the output key names of record attributes, an empty key omits the attribute.
+type RecordKeys struct {
+	TimeKey    string `json:"timeKey"`
+	LevelKey   string `json:"levelKey"`
+	NameKey    string `json:"nameKey"`
+	CallerKey  string `json:"callerKey"`
+	MessageKey string `json:"messageKey"`
+	ErrorKey   string `json:"errorKey"`
+}
+
+// DefaultRecordKeys is the default key names
+var DefaultRecordKeys = RecordKeys{
+	TimeKey:    "time",
+	LevelKey:   "level",
+	NameKey:    "logger",
+	CallerKey:  "caller",
+	MessageKey: "msg",
+	ErrorKey:   "error",
+}
+
+// JSONFormatter formats a record to a JSON object in one line
+type JSONFormatter struct {
+	RecordKeys
+	TimeFmt string   `json:"timefmt"`
+	Include []string `json:"include"` // extra attributes: lfile, sfile, func, line
+}
+
+// NewJSONFormatter return a new JSONFormatter
+func NewJSONFormatter() *JSONFormatter {
+	jf := &JSONFormatter{
+		RecordKeys: DefaultRecordKeys,
+		TimeFmt:    time.RFC3339Nano,
+	}
+	return jf
+}
+
+// NeedsCaller reports whether the caller or any attribute of Include is output
+func (jf *JSONFormatter) NeedsCaller() bool {
+	return jf.CallerKey != "" || len(jf.Include) > 0
+}
+
+// Format formats a record to JSON
+func (jf *JSONFormatter) Format(rec *glogger.Record) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if jf.TimeKey != "" {
+		writeJSONField(&buf, jf.TimeKey, rec.Time.Format(jf.TimeFmt))
+	}
+	if jf.LevelKey != "" {
+		writeJSONField(&buf, jf.LevelKey, rec.Level.String())
+	}
+	if jf.NameKey != "" {
+		writeJSONField(&buf, jf.NameKey, rec.Name)
+	}
+	if jf.CallerKey != "" {
+		writeJSONField(&buf, jf.CallerKey, rec.SFile+":"+strconv.Itoa(rec.Line))
+	}
+	for _, attr := range jf.Include {
+		switch attr {
+		case "lfile":
+			writeJSONField(&buf, attr, rec.LFile)
+		case "sfile":
+			writeJSONField(&buf, attr, rec.SFile)
+		case "func":
+			writeJSONField(&buf, attr, rec.Func)
+		case "line":
+			writeJSONField(&buf, attr, rec.Line)
+		}
+	}
+	if jf.MessageKey != "" {
+		writeJSONField(&buf, jf.MessageKey, rec.Message)
+	}
+	if jf.ErrorKey != "" && rec.Err != nil {
+		writeJSONField(&buf, jf.ErrorKey, rec.Err.Error())
+	}
+	for _, f := range rec.Fields {
+		writeJSONField(&buf, f.Key, f.Value)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// writeJSONField writes "key":value, invalid UTF-8 is replaced by encoding/json.
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(v)
+}
+
+// includable is the set of attributes can be selected by Include
+var includable = map[string]bool{
+	"lfile": true,
+	"sfile": true,
+	"func":  true,
+	"line":  true,
+}
+
+// LoadConfig load configuration from a map
+func (jf *JSONFormatter) LoadConfig(config map[string]interface{}) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, jf); err != nil {
+		return err
+	}
+	return checkInclude(jf.Include)
+}
+
+func checkInclude(include []string) error {
+	for _, attr := range include {
+		if !includable[attr] {
+			return fmt.Errorf("unknown include attribute: %s", attr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package formatters
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func TestJSONFormatterValid(t *testing.T) {
+	jf := NewJSONFormatter()
+	jf.Include = []string{"func", "line"}
+	msg := "say \"hi\"\nbad \xff utf8"
+	rec := glogger.NewRecord("main", time.Now(), glogger.InfoLevel, "/tmp/main.go", "main.main", 7, msg)
+	rec.Fields = glogger.MakeFields("user", "bob", "count", 3)
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(jf.Format(rec)), &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if m["msg"] != "say \"hi\"\nbad � utf8" {
+		t.Errorf("msg = %q", m["msg"])
+	}
+	if m["caller"] != "main.go:7" || m["line"] != 7.0 || m["user"] != "bob" || m["count"] != 3.0 {
+		t.Errorf("unexpected object: %v", m)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func init() {
+	glogger.RegisterConfigLoaderBuilder("github.com/Xuyuanp/glogger/formatters.LogfmtFormatter", func() glogger.ConfigLoader {
+		return NewLogfmtFormatter()
+	})
+}
+
+// LogfmtFormatter formats a record to key=value pairs, values are quoted if needed
+type LogfmtFormatter struct {
+	RecordKeys
+	TimeFmt string   `json:"timefmt"`
+	Include []string `json:"include"` // extra attributes: lfile, sfile, func, line
+}
+
+// NewLogfmtFormatter return a new LogfmtFormatter
+func NewLogfmtFormatter() *LogfmtFormatter {
+	lf := &LogfmtFormatter{
+		RecordKeys: DefaultRecordKeys,
+		TimeFmt:    glogger.DefaultTimeFormat,
+	}
+	return lf
+}
+
+// NeedsCaller reports whether the caller or any attribute of Include is output
+func (lf *LogfmtFormatter) NeedsCaller() bool {
+	return lf.CallerKey != "" || len(lf.Include) > 0
+}
+
+// Format formats a record to logfmt
+func (lf *LogfmtFormatter) Format(rec *glogger.Record) string {
+	var buf bytes.Buffer
+	if lf.TimeKey != "" {
+		writeLogfmtPair(&buf, lf.TimeKey, rec.Time.Format(lf.TimeFmt))
+	}
+	if lf.LevelKey != "" {
+		writeLogfmtPair(&buf, lf.LevelKey, rec.Level.String())
+	}
+	if lf.NameKey != "" {
+		writeLogfmtPair(&buf, lf.NameKey, rec.Name)
+	}
+	if lf.CallerKey != "" {
+		writeLogfmtPair(&buf, lf.CallerKey, rec.SFile+":"+strconv.Itoa(rec.Line))
+	}
+	for _, attr := range lf.Include {
+		switch attr {
+		case "lfile":
+			writeLogfmtPair(&buf, attr, rec.LFile)
+		case "sfile":
+			writeLogfmtPair(&buf, attr, rec.SFile)
+		case "func":
+			writeLogfmtPair(&buf, attr, rec.Func)
+		case "line":
+			writeLogfmtPair(&buf, attr, strconv.Itoa(rec.Line))
+		}
+	}
+	if lf.MessageKey != "" {
+		writeLogfmtPair(&buf, lf.MessageKey, rec.Message)
+	}
+	if lf.ErrorKey != "" && rec.Err != nil {
+		writeLogfmtPair(&buf, lf.ErrorKey, rec.Err.Error())
+	}
+	for _, f := range rec.Fields {
+		if err, ok := f.Value.(error); ok {
+			writeLogfmtPair(&buf, f.Key, err.Error())
+		} else {
+			writeLogfmtPair(&buf, f.Key, fmt.Sprint(f.Value))
+		}
+	}
+	return buf.String()
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	if needsQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+// logfmtKey replaces the characters not allowed in a key with '_'
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig load configuration from a map
+func (lf *LogfmtFormatter) LoadConfig(config map[string]interface{}) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, lf); err != nil {
+		return err
+	}
+	return checkInclude(lf.Include)
+}
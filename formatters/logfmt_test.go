@@ -0,0 +1,20 @@
+package formatters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func TestLogfmtFormatterQuoting(t *testing.T) {
+	lf := NewLogfmtFormatter()
+	lf.TimeKey = ""
+	rec := glogger.NewRecord("main", time.Now(), glogger.WarnLevel, "/tmp/main.go", "main.main", 7, "a \"b\"=c\n")
+	rec.Fields = glogger.MakeFields("empty", "", "bad key", "x\xff", "plain", 1)
+
+	want := `level=WARN logger=main caller=main.go:7 msg="a \"b\"=c\n" empty="" bad_key="x\xff" plain=1`
+	if got := lf.Format(rec); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -17,7 +17,7 @@
 package formatters
 
 import (
-	"fmt"
+	"bytes"
 
 	"github.com/Xuyuanp/glogger"
 )
@@ -48,52 +48,100 @@ type RainbowFormatter struct {
 func NewRainbowFormatter() *RainbowFormatter {
 	rf := &RainbowFormatter{
 		DefaultFormatter: &glogger.DefaultFormatter{
-			Fmt:     defaultRainbowFormat,
 			TimeFmt: glogger.DefaultTimeFormat,
 		},
-		LevelColors: defaultLevelColors,
+		LevelColors: make(map[glogger.LogLevel]string, len(defaultLevelColors)),
 	}
+	for level, color := range defaultLevelColors {
+		rf.LevelColors[level] = color
+	}
+	rf.SetFmt(defaultRainbowFormat)
 	return rf
 }
 
 // Format format record colorized
 func (rf *RainbowFormatter) Format(rec *glogger.Record) string {
-	newFmt := rf.DefaultFormatter.Format(rec)
+	return rf.FormatWith(rec, rf.writeColor) + EscapeCodes["reset"]
+}
 
-	newFmt = glogger.FieldHolderRegexp.ReplaceAllStringFunc(newFmt, func(match string) string {
-		m := match[2 : len(match)-1]
-		if m == "log_color" {
-			m, _ = rf.LevelColors[rec.Level]
+// writeColor writes the escape code of color placeholders, and the colored stack
+func (rf *RainbowFormatter) writeColor(buf *bytes.Buffer, rec *glogger.Record, name string) bool {
+	if name == "stack" {
+		// function names in bold and file names dimmed, the colors are reset after each
+		glogger.WriteStack(buf, rec, EscapeCodes["bold"], EscapeCodes["dim"], EscapeCodes["reset"])
+		return true
+	}
+	if name == "log_color" {
+		name = rf.levelColor(rec.Level)
+	}
+	code, ok := EscapeCodes[name]
+	if ok {
+		buf.WriteString(code)
+	}
+	return ok
+}
+
+// levelColor return the color of level. Levels without color, like custom levels, use the color
+// of the nearest lower level with color, or the lowest one if there isn't such level.
+func (rf *RainbowFormatter) levelColor(level glogger.LogLevel) string {
+	if color, ok := rf.LevelColors[level]; ok {
+		return color
+	}
+	var lower, lowest glogger.LogLevel
+	var hasLower, hasLowest bool
+	for l := range rf.LevelColors {
+		if l < level && (!hasLower || l > lower) {
+			lower, hasLower = l, true
 		}
-		code, ok := EscapeCodes[m]
-		if ok {
-			return code
+		if !hasLowest || l < lowest {
+			lowest, hasLowest = l, true
 		}
-		return match
-	})
-
-	newFmt += EscapeCodes["reset"]
-
-	return newFmt
+	}
+	if hasLower {
+		return rf.LevelColors[lower]
+	}
+	return rf.LevelColors[lowest]
 }
 
 // LoadConfig load configuration from a map
 func (rf *RainbowFormatter) LoadConfig(config map[string]interface{}) error {
-	if format, ok := config["fmt"]; ok {
-		rf.Fmt = format.(string)
+	c := glogger.NewConfigChecker("", config)
+	if format, ok := c.String("fmt", false); ok {
+		rf.SetFmt(format)
 	}
-	if timefmt, ok := config["timefmt"]; ok {
-		rf.TimeFmt = timefmt.(string)
+	if timefmt, ok := c.String("timefmt", false); ok {
+		rf.TimeFmt = timefmt
 	}
-	if colors, ok := config["colors"]; ok {
-		colorConfig := colors.(map[string]interface{})
-		for name, level := range glogger.StringToLevel {
-			if colori, yes := colorConfig[name]; yes {
-				rf.LevelColors[level] = colori.(string)
-			} else {
-				return fmt.Errorf("unknown color: " + name)
+	// levels missing from colors keep their default colors
+	if colors, ok := c.Map("colors", false); ok {
+		cc := glogger.NewConfigChecker("colors", colors)
+		for name := range colors {
+			level, err := glogger.ParseLevel(name)
+			if err != nil {
+				cc.Errorf(name, "%s", err)
+				continue
+			}
+			if color, ok := cc.String(name, false); ok {
+				if _, ok := EscapeCodes[color]; !ok {
+					cc.Errorf(name, "unknown color: %s", color)
+				}
+				rf.LevelColors[level] = color
 			}
 		}
+		c.Add(cc.Errors()...)
+	}
+	return c.Err()
+}
+
+// ExportConfig exports the colors by level names as well as the formats
+func (rf *RainbowFormatter) ExportConfig() map[string]interface{} {
+	colors := make(map[string]interface{}, len(rf.LevelColors))
+	for level, color := range rf.LevelColors {
+		colors[glogger.LevelName(level)] = color
+	}
+	return map[string]interface{}{
+		"fmt":     rf.Fmt,
+		"timefmt": rf.TimeFmt,
+		"colors":  colors,
 	}
-	return nil
 }
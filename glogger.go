@@ -16,47 +16,14 @@
 
 package glogger
 
-import "fmt"
-
-// LogLevel type
-type LogLevel uint8
-
-// AutoRoot is a switcher that controlls GetLogger result
-var AutoRoot = true
-
-// LogLevel values
-const (
-	DebugLevel LogLevel = iota
-	InfoLevel
-	WarnLevel
-	ErrorLevel
-	CriticalLevel
+import (
+	"strings"
+	"sync"
 )
 
-func (level LogLevel) String() string {
-	if str, ok := LevelToString[level]; ok {
-		return str
-	}
-	return fmt.Sprintf("unknow LogLeve(%d)", level)
-}
-
-// LevelToString is a map to translate LogLevel to a level name string
-var LevelToString = map[LogLevel]string{
-	DebugLevel:    "DBUG",
-	InfoLevel:     "INFO",
-	WarnLevel:     "WARN",
-	ErrorLevel:    "ERRO",
-	CriticalLevel: "CRIT",
-}
-
-// StringToLevel is a map to translate level name to LogLevel type
-var StringToLevel = map[string]LogLevel{
-	"DEBUG":    DebugLevel,
-	"INFO":     InfoLevel,
-	"WARNING":  WarnLevel,
-	"ERROR":    ErrorLevel,
-	"CRITICAL": CriticalLevel,
-}
+// AutoRoot is a switcher that controlls GetLogger result. If it's true, GetLogger
+// creates the missing Logger as a child of its nearest ancestor.
+var AutoRoot = true
 
 // Leveler is an interface provided set/get LogLevel method
 type Leveler interface {
@@ -66,31 +33,107 @@ type Leveler interface {
 
 var loggerRegister = NewRegister()
 
-// GetLogger return a Logger registered with the name,
-// or the root Logger if AutoRoot is true and name is not root, or nil
+// loggerTreeMu guards the parent links between registered loggers
+var loggerTreeMu sync.Mutex
+
+// GetLogger return a Logger registered with the name. If there is no such Logger
+// and AutoRoot is true, a new Logger with NotSetLevel is created and registered.
+// Dotted names make up a hierarchy, e.g. "app.db.pool" is a child of "app.db",
+// which is a child of "app", and all of them are descendants of root.
 func GetLogger(name string) *Logger {
 	if v := loggerRegister.Get(name); v != nil {
 		return v.(*Logger)
 	}
 	if AutoRoot && name != "root" {
-		return loggerRegister.Get("root").(*Logger)
+		return getOrCreateLogger(name)
 	}
 	return nil
 }
 
+func getOrCreateLogger(name string) *Logger {
+	loggerTreeMu.Lock()
+	defer loggerTreeMu.Unlock()
+	if v := loggerRegister.Get(name); v != nil {
+		return v.(*Logger)
+	}
+	l := NewLogger()
+	l.SetLevel(NotSetLevel)
+	registerLogger(name, l)
+	return l
+}
+
+// LoggerNames return the names of all the registered Loggers in order
+func LoggerNames() []string {
+	return loggerRegister.Names()
+}
+
 // UnregisterLogger unregister the logger from global manager, this will make the logger
 // unreachable for others and return the logger, this's the last chance getting it.
+// The children of the logger will be attached to its parent.
 // If this Logger hasn't been registered, nothing will happen and return nil
 func UnregisterLogger(name string) *Logger {
-	if l := loggerRegister.Unregister(name); l != nil {
-		return l.(*Logger)
+	loggerTreeMu.Lock()
+	defer loggerTreeMu.Unlock()
+	v := loggerRegister.Unregister(name)
+	if v == nil {
+		return nil
 	}
-	return nil
+	l := v.(*Logger)
+	loggerRegister.Range(func(_ string, v interface{}) bool {
+		if child := v.(*Logger); child.Parent() == l {
+			child.setParent(l.Parent())
+		}
+		return true
+	})
+	l.setParent(nil)
+	return l
 }
 
 // RegisterLogger will register the logger to global manager. The logger registered can be
-// accessed by GetLogger() method with logger's name.
+// accessed by GetLogger() method with logger's name. The logger will be linked into the
+// hierarchy, becoming the parent of registered loggers below it.
 func RegisterLogger(name string, l *Logger) {
+	loggerTreeMu.Lock()
+	defer loggerTreeMu.Unlock()
+	registerLogger(name, l)
+}
+
+func registerLogger(name string, l *Logger) {
 	loggerRegister.Register(name, l)
 	l.Name = name
+	if name == "root" {
+		loggerRegister.Range(func(n string, v interface{}) bool {
+			if child := v.(*Logger); child != l && child.Parent() == nil {
+				child.setParent(l)
+			}
+			return true
+		})
+		return
+	}
+	l.setParent(nearestAncestor(name))
+	prefix := name + "."
+	loggerRegister.Range(func(n string, v interface{}) bool {
+		child := v.(*Logger)
+		if !strings.HasPrefix(n, prefix) {
+			return true
+		}
+		// the child's parent is an ancestor of l, not a descendant
+		if parent := child.Parent(); parent == nil || !strings.HasPrefix(parent.Name, prefix) {
+			child.setParent(l)
+		}
+		return true
+	})
+}
+
+// nearestAncestor return the registered Logger closest to name in the hierarchy, or nil.
+func nearestAncestor(name string) *Logger {
+	for i := strings.LastIndex(name, "."); i > 0; i = strings.LastIndex(name[:i], ".") {
+		if v := loggerRegister.Get(name[:i]); v != nil {
+			return v.(*Logger)
+		}
+	}
+	if v := loggerRegister.Get("root"); v != nil {
+		return v.(*Logger)
+	}
+	return nil
 }
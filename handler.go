@@ -17,12 +17,13 @@
 package glogger
 
 import (
-	"container/list"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Handler determines where the log message to output
@@ -40,125 +41,224 @@ func RegisterHandler(name string, handler Handler) {
 	handlerRegister.Register(name, handler)
 }
 
+// ReplaceHandler register a Handler with the name whether the name has been registered or not,
+// and return the Handler registered before or nil.
+func ReplaceHandler(name string, handler Handler) Handler {
+	if v := handlerRegister.Replace(name, handler); v != nil {
+		return v.(Handler)
+	}
+	return nil
+}
+
+// HandlerNames return the names of all the registered Handlers in order
+func HandlerNames() []string {
+	return handlerRegister.Names()
+}
+
 // GetHandler return the Handler registered with this name.
 // nil will by returned if no Handler registered with this name.
 func GetHandler(name string) Handler {
-	return handlerRegister.Get(name).(Handler)
 	if v := handlerRegister.Get(name); v != nil {
 		return v.(Handler)
 	}
 	return nil
 }
 
+// handlerGroup is safe for concurrent use, handling reads a snapshot of
+// the handler list which is replaced on modification.
 type handlerGroup struct {
-	handlers *list.List
+	mu       sync.Mutex   // serializes modifications
+	handlers atomic.Value // []Handler
 }
 
 func (hg *handlerGroup) AddHandler(h Handler) {
-	if hg.handlers == nil {
-		hg.handlers = list.New()
-	}
-	hg.handlers.PushBack(h)
+	hg.mu.Lock()
+	defer hg.mu.Unlock()
+	old := hg.handlerList()
+	handlers := make([]Handler, 0, len(old)+1)
+	hg.handlers.Store(append(append(handlers, old...), h))
 }
 
 func (hg *handlerGroup) SetHandlers(handlers ...Handler) {
-	hg.ClearHandlers()
-	for _, h := range handlers {
-		hg.handlers.PushBack(h)
-	}
+	hg.mu.Lock()
+	defer hg.mu.Unlock()
+	hg.handlers.Store(append([]Handler{}, handlers...))
 }
 
 func (hg *handlerGroup) ClearHandlers() {
-	hg.handlers = list.New()
+	hg.SetHandlers()
+}
+
+// handlerList return the snapshot of handlers in order, which mustn't be modified
+func (hg *handlerGroup) handlerList() []Handler {
+	handlers, _ := hg.handlers.Load().([]Handler)
+	return handlers
+}
+
+// needsCaller reports whether any of the handlers needs the caller of records
+func (hg *handlerGroup) needsCaller() bool {
+	for _, h := range hg.handlerList() {
+		if needsCaller(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// handling counts the calls of handlerGroup.Handle by the epoch they started in,
+// so replaced handlers are closed only after the calls which may still use them returned.
+var handling struct {
+	mu     sync.Mutex // serializes waiting
+	epoch  uint32
+	active [2]int32 // the calls started in even and odd epochs
+}
+
+// enterHandling counts a call of handlerGroup.Handle, the returned counter is decremented when it returns
+func enterHandling() *int32 {
+	for {
+		epoch := atomic.LoadUint32(&handling.epoch)
+		active := &handling.active[epoch&1]
+		atomic.AddInt32(active, 1)
+		if atomic.LoadUint32(&handling.epoch) == epoch {
+			return active
+		}
+		atomic.AddInt32(active, -1)
+	}
+}
+
+// waitHandling waits until the calls of handlerGroup.Handle started before it returned,
+// the ones started afterwards read the current handler lists.
+func waitHandling() {
+	handling.mu.Lock()
+	defer handling.mu.Unlock()
+	epoch := atomic.AddUint32(&handling.epoch, 1) - 1
+	for atomic.LoadInt32(&handling.active[epoch&1]) != 0 {
+		time.Sleep(time.Millisecond)
+	}
 }
 
 func (hg *handlerGroup) Handle(rec *Record) {
-	if hg.handlers == nil {
-		return
-	}
-	for e := hg.handlers.Front(); e != nil; e = e.Next() {
-		var h = e.Value.(Handler)
-		func() {
-			if rec.Level < h.Level() || !h.Filter(rec) {
-				return
-			}
+	active := enterHandling()
+	defer atomic.AddInt32(active, -1)
+	for _, h := range hg.handlerList() {
+		if rec.Level < h.Level() {
+			continue
+		}
+		rec.handler = h
+		pass := h.Filter(rec)
+		rec.handler = nil
+		if pass {
 			h.Handle(rec)
-		}()
+		}
 	}
 }
 
 // GenericHandler is an abstract struct which fully implemented Handler interface
 // expected Emit method.
+// Level and formatter can be changed while handling records.
 type GenericHandler struct {
 	GroupFilter
-	level     LogLevel
-	formatter Formatter
+	level     int32        // LogLevel
+	formatter atomic.Value // formatterHolder
+}
+
+// formatterHolder keeps the concrete type stored in atomic.Value the same
+type formatterHolder struct {
+	Formatter
 }
 
 // NewHandler return a new GenericHandler
 func NewHandler() *GenericHandler {
-	gh := &GenericHandler{
-		level:     DebugLevel,
-		formatter: NewDefaultFormatter(),
-	}
+	gh := &GenericHandler{}
+	gh.SetLevel(DebugLevel)
+	gh.SetFormatter(NewDefaultFormatter())
 	return gh
 }
 
+// Formatter return the Formatter of the handler
+func (gh *GenericHandler) Formatter() Formatter {
+	holder, _ := gh.formatter.Load().(formatterHolder)
+	return holder.Formatter
+}
+
 // Format a record with formatter
 func (gh *GenericHandler) Format(rec *Record) string {
-	return gh.formatter.Format(rec)
+	return gh.Formatter().Format(rec)
 }
 
 // SetFormatter set a new Formatter
 func (gh *GenericHandler) SetFormatter(formatter Formatter) {
-	gh.formatter = formatter
+	gh.formatter.Store(formatterHolder{formatter})
+}
+
+// NeedsCaller reports whether the formatter or filters need the caller of records
+func (gh *GenericHandler) NeedsCaller() bool {
+	return needsCaller(gh.Formatter()) || gh.GroupFilter.needsCaller()
 }
 
 // Level return log level of the handler
 func (gh *GenericHandler) Level() LogLevel {
-	return gh.level
+	return LogLevel(atomic.LoadInt32(&gh.level))
 }
 
 // SetLevel set log level of handler
 func (gh *GenericHandler) SetLevel(level LogLevel) {
-	gh.level = level
+	atomic.StoreInt32(&gh.level, int32(level))
+}
+
+// ValidateConfig checks the level, formatter and filters fields
+func (gh *GenericHandler) ValidateConfig(config map[string]interface{}) []error {
+	c := NewConfigChecker("", config)
+	c.Level("level", false)
+	c.String("formatter", false)
+	c.Strings("filters", false)
+	return c.Errors()
 }
 
 // LoadConfig load configuration from a map
 func (gh *GenericHandler) LoadConfig(config map[string]interface{}) error {
+	c := NewConfigChecker("", config)
 	// Load log level, default DebugLevel
-	if level, ok := config["level"]; ok {
-		if l, ok := StringToLevel[level.(string)]; ok {
-			gh.level = l
-		} else {
-			return fmt.Errorf("unknown log level: %s", level.(string))
-		}
-	} else {
-		gh.level = DebugLevel
+	level, ok := c.Level("level", false)
+	if !ok {
+		level = DebugLevel
 	}
+	gh.SetLevel(level)
 	// Load Formatter, default is DefaultFormatter
-	if formatter, ok := config["formatter"]; ok {
-		if f := GetFormatter(formatter.(string)); f != nil {
-			gh.formatter = f
+	if name, ok := c.String("formatter", false); ok {
+		if f := GetFormatter(name); f != nil {
+			gh.SetFormatter(f)
 		} else {
-			return fmt.Errorf("unknown formater name: " + formatter.(string))
+			c.Errorf("formatter", "unknown formatter name: %s", name)
 		}
 	} else {
-		gh.formatter = NewDefaultFormatter()
+		gh.SetFormatter(NewDefaultFormatter())
 	}
 	// Load filters
-	if filters, ok := config["filters"]; ok {
-		if len(filters.([]interface{})) > 0 {
-			for _, fname := range filters.([]interface{}) {
-				if filter := GetFilter(fname.(string)); filter != nil {
-					gh.AddFilter(filter)
-				} else {
-					return fmt.Errorf("unknown filter name: %s", fname.(string))
-				}
+	if names, ok := c.Strings("filters", false); ok {
+		for i, name := range names {
+			if filter := GetFilter(name); filter != nil {
+				gh.AddFilter(filter)
+			} else {
+				c.Errorf(fmt.Sprintf("filters[%d]", i), "unknown filter name: %s", name)
 			}
 		}
 	}
-	return nil
+	return c.Err()
+}
+
+// ExportConfig exports the level, formatter and filters
+func (gh *GenericHandler) ExportConfig() map[string]interface{} {
+	m := map[string]interface{}{
+		"level": LevelName(gh.Level()),
+	}
+	if name := registeredName(formatterRegister, gh.Formatter()); name != "" {
+		m["formatter"] = name
+	}
+	if filters := filterNames(gh.filterList()); len(filters) > 0 {
+		m["filters"] = filters
+	}
+	return m
 }
 
 func init() {
@@ -170,29 +270,26 @@ func init() {
 // StreamHandler struct
 type StreamHandler struct {
 	*GenericHandler
-	nestedLogger *log.Logger
-	mu           sync.Mutex
+	nestedLogger atomic.Value // *log.Logger, replaced by SetWriter
 }
 
 // NewStreamHandler return a new StreamHandler
 func NewStreamHandler() *StreamHandler {
 	sh := &StreamHandler{
 		GenericHandler: NewHandler(),
-		nestedLogger:   log.New(os.Stdout, "", 0),
 	}
+	sh.SetWriter(os.Stdout)
 	return sh
 }
 
 // Handle a Record
 func (sh *StreamHandler) Handle(rec *Record) {
-	sh.nestedLogger.Println(sh.Format(rec))
+	sh.nestedLogger.Load().(*log.Logger).Println(sh.Format(rec))
 }
 
-// SetWriter set a output writer
+// SetWriter set a output writer, it's safe to call while handling records
 func (sh *StreamHandler) SetWriter(writer io.Writer) {
-	sh.mu.Lock()
-	defer sh.mu.Unlock()
-	sh.nestedLogger = log.New(writer, "", 0)
+	sh.nestedLogger.Store(log.New(writer, "", 0))
 }
 
 var writerMap = map[string]io.Writer{
@@ -200,17 +297,41 @@ var writerMap = map[string]io.Writer{
 	"stderr": os.Stderr,
 }
 
+// ValidateConfig checks the fields of StreamHandler
+func (sh *StreamHandler) ValidateConfig(config map[string]interface{}) []error {
+	c := NewConfigChecker("", config)
+	if writer, ok := c.String("writer", false); ok {
+		if _, ok := writerMap[writer]; !ok {
+			c.Errorf("writer", "unknown writer: %s", writer)
+		}
+	}
+	return append(sh.GenericHandler.ValidateConfig(config), c.Errors()...)
+}
+
 // LoadConfig load configuration from a map
 func (sh *StreamHandler) LoadConfig(config map[string]interface{}) error {
-	sh.GenericHandler.LoadConfig(config)
+	if err := JoinConfigErrors(sh.ValidateConfig(config)); err != nil {
+		return err
+	}
+	if err := sh.GenericHandler.LoadConfig(config); err != nil {
+		return err
+	}
 	if writer, ok := config["writer"]; ok {
-		if w, ok := writerMap[writer.(string)]; ok {
-			sh.SetWriter(w)
-		} else {
-			return fmt.Errorf("unknown writer: " + writer.(string))
-		}
+		sh.SetWriter(writerMap[writer.(string)])
 	} else {
 		sh.SetWriter(os.Stdout)
 	}
 	return nil
 }
+
+// ExportConfig exports the writer as well as the fields of GenericHandler
+func (sh *StreamHandler) ExportConfig() map[string]interface{} {
+	m := sh.GenericHandler.ExportConfig()
+	writer := sh.nestedLogger.Load().(*log.Logger).Writer()
+	for name, w := range writerMap {
+		if w == writer {
+			m["writer"] = name
+		}
+	}
+	return m
+}
@@ -0,0 +1,148 @@
+package glogger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stressLogger logs on l from several goroutines until the returned function is called
+func stressLogger(l *Logger) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					l.Infow("stress", "k", "v")
+				}
+			}
+		}()
+	}
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// TestConcurrentReconfiguration should be run with -race
+func TestConcurrentReconfiguration(t *testing.T) {
+	l := NewLogger()
+	h := NewStreamHandler()
+	h.SetWriter(ioutil.Discard)
+	l.AddHandler(h)
+
+	stop := stressLogger(l)
+	pass := &GroupFilter{}
+	for i := 0; i < 1000; i++ {
+		other := NewStreamHandler()
+		other.SetWriter(ioutil.Discard)
+		l.AddHandler(other)
+		l.SetHandlers(h, other)
+		l.AddFilter(pass)
+		l.SetLevel(LogLevel(i % 3))
+		h.AddFilter(pass)
+		h.SetLevel(LogLevel(i % 2))
+		f := NewDefaultFormatter()
+		f.SetFmt("${levelname} ${msg}")
+		h.SetFormatter(f)
+		h.SetWriter(ioutil.Discard)
+		if i%100 == 0 {
+			l.ClearHandlers()
+			l.ClearFilters()
+			h.ClearFilters()
+			l.AddHandler(h)
+		}
+	}
+	stop()
+
+	// reloading configurations of a registered logger, and relinking it
+	ReplaceHandler("concurrent-discard", h)
+	defer handlerRegister.Unregister("concurrent-discard")
+	parent, child := GetLogger("concurrent"), GetLogger("concurrent.mid.child")
+	defer func() {
+		child.Close()
+		UnregisterLogger("concurrent.mid.child")
+		UnregisterLogger("concurrent")
+	}()
+	parent.SetHandlers(h)
+	parent.SetPropagate(false)
+
+	stop = stressLogger(child)
+	policies := []string{"block", "drop_newest", "drop_oldest"}
+	modes := []string{"always", "never", "auto"}
+	for i := 0; i < 200; i++ {
+		conf := fmt.Sprintf(`{"loggers": {"concurrent.mid.child": {
+			"handlers": ["concurrent-discard"], "propagate": %v, "async": %d, "overflow": %q, "caller": %q
+		}}}`, i%2 == 0, i%3*4, policies[i%3], modes[i%3])
+		if err := LoadConfig([]byte(conf), WithConfigMode(MergeMode)); err != nil {
+			t.Fatal(err)
+		}
+		child.SetPropagate(i%2 == 1)
+		if i%2 == 0 {
+			GetLogger("concurrent.mid")
+		} else {
+			UnregisterLogger("concurrent.mid")
+		}
+	}
+	stop()
+}
+
+// gateHandler blocks handling until gate is closed, and counts the records handled after Close
+type gateHandler struct {
+	*StreamHandler
+	entered, gate chan struct{}
+	closed, late  int32
+}
+
+func (gh *gateHandler) Handle(rec *Record) {
+	close(gh.entered)
+	<-gh.gate
+	if atomic.LoadInt32(&gh.closed) != 0 {
+		atomic.AddInt32(&gh.late, 1)
+	}
+}
+
+func (gh *gateHandler) Close() error {
+	atomic.StoreInt32(&gh.closed, 1)
+	return nil
+}
+
+func TestCloseReplacedHandlers(t *testing.T) {
+	restoreRegisters(t)
+	h := &gateHandler{StreamHandler: NewStreamHandler(), entered: make(chan struct{}), gate: make(chan struct{})}
+	ReplaceHandler("inflight", h)
+	l := NewLogger()
+	l.SetHandlers(h)
+	go l.Info("in flight")
+	<-h.entered
+
+	other := NewStreamHandler()
+	other.SetWriter(ioutil.Discard)
+	ReplaceHandler("inflight", other)
+	l.SetHandlers(other)
+	closed := make(chan struct{})
+	go func() {
+		closeUnusedHandlers(map[string]interface{}{"inflight": h})
+		close(closed)
+	}()
+	l.Info("new handlers")
+	select {
+	case <-closed:
+		t.Fatal("replaced handler shouldn't be closed while handling")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(h.gate)
+	<-closed
+	if c, late := atomic.LoadInt32(&h.closed), atomic.LoadInt32(&h.late); c == 0 || late != 0 {
+		t.Errorf("handler should be closed after handling: closed=%d late=%d", c, late)
+	}
+}
@@ -34,6 +34,7 @@ func init() {
 type FileHandler struct {
 	*glogger.StreamHandler
 	FileName string
+	file     *os.File
 	mu       sync.Mutex
 }
 
@@ -45,28 +46,76 @@ func NewFileHandler() *FileHandler {
 	return fh
 }
 
-// SetFileName set the name of file to output
+// SetFileName set the name of file to output, errors are printed to stderr. See OpenFile.
 func (fh *FileHandler) SetFileName(fileName string) {
+	if err := fh.OpenFile(fileName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// OpenFile opens the file to output, the file opened before is closed only if it succeeds.
+func (fh *FileHandler) OpenFile(fileName string) error {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
-	fh.FileName = fileName
-	file, err := os.OpenFile(fh.FileName, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0640)
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0640)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
+		return err
 	}
+	fh.FileName = fileName
 	fh.SetWriter(file)
+	if fh.file != nil {
+		fh.file.Close()
+	}
+	fh.file = file
+	return nil
+}
+
+// Flush commits the log file to stable storage
+func (fh *FileHandler) Flush() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.file == nil {
+		return nil
+	}
+	return fh.file.Sync()
+}
+
+// Close closes the log file
+func (fh *FileHandler) Close() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.file == nil {
+		return nil
+	}
+	err := fh.file.Close()
+	fh.file = nil
+	return err
+}
+
+// ValidateConfig checks the fields of FileHandler
+func (fh *FileHandler) ValidateConfig(config map[string]interface{}) []error {
+	c := glogger.NewConfigChecker("", config)
+	c.String("filename", true)
+	return append(fh.GenericHandler.ValidateConfig(config), c.Errors()...)
 }
 
 // LoadConfig load configuration from a map
 func (fh *FileHandler) LoadConfig(config map[string]interface{}) error {
+	if err := glogger.JoinConfigErrors(fh.ValidateConfig(config)); err != nil {
+		return err
+	}
 	if err := fh.GenericHandler.LoadConfig(config); err != nil {
 		return err
 	}
-	if filename, ok := config["filename"]; ok {
-		fh.SetFileName(filename.(string))
-	} else {
-		return fmt.Errorf("'filename' field is required")
+	if err := fh.OpenFile(config["filename"].(string)); err != nil {
+		return &glogger.ConfigError{Path: "filename", Msg: err.Error()}
 	}
 	return nil
 }
+
+// ExportConfig exports the filename as well as the fields of GenericHandler
+func (fh *FileHandler) ExportConfig() map[string]interface{} {
+	m := fh.GenericHandler.ExportConfig()
+	m["filename"] = fh.FileName
+	return m
+}
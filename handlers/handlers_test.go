@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Xuyuanp/glogger"
+)
+
+func TestReloadFileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "app.log")
+	conf := func(builder, fileName string) []byte {
+		return []byte(`{
+			"handlers": {"reload-file": {"builder": "github.com/Xuyuanp/glogger/handlers.` + builder + `", "filename": "` + fileName + `"}},
+			"loggers": {"reload-file": {"handlers": ["reload-file"], "propagate": false}}
+		}`)
+	}
+	defer glogger.UnregisterLogger("reload-file")
+
+	for _, builder := range []string{"FileHandler", "RotatingFileHandler"} {
+		if err := glogger.LoadConfig(conf(builder, fileName), glogger.WithConfigMode(glogger.MergeMode)); err != nil {
+			t.Fatal(err)
+		}
+		h := glogger.GetHandler("reload-file")
+		err := glogger.LoadConfig(conf(builder, "/nonexistent/dir/x.log"), glogger.WithConfigMode(glogger.MergeMode))
+		if err == nil || !strings.Contains(err.Error(), "handlers.reload-file.filename") {
+			t.Fatalf("%s: expected error opening the file, got %v", builder, err)
+		}
+		if glogger.GetHandler("reload-file") != h {
+			t.Fatalf("%s: failed reload should keep the working handler", builder)
+		}
+		glogger.GetLogger("reload-file").Error("kept %s", builder)
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "kept "+builder) {
+			t.Errorf("%s: record should be written to the old file: %q", builder, data)
+		}
+	}
+}
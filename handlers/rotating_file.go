@@ -81,12 +81,33 @@ func (fh *RotatingFileHandler) Handle(rec *glogger.Record) {
 	}
 }
 
-// SetFileName set the name of file to output
-func (fh *RotatingFileHandler) setFileName(fileName string) {
+// Flush commits the log file to stable storage
+func (fh *RotatingFileHandler) Flush() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.File == nil {
+		return nil
+	}
+	return fh.File.Sync()
+}
+
+// Close closes the log file
+func (fh *RotatingFileHandler) Close() error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.File == nil {
+		return nil
+	}
+	err := fh.File.Close()
+	fh.File = nil
+	return err
+}
+
+// setFileName opens the file to output, the file opened before is closed only if it succeeds.
+func (fh *RotatingFileHandler) setFileName(fileName string) error {
 	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0640)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
+		return err
 	}
 
 	if fh.AutoRotate {
@@ -107,6 +128,7 @@ func (fh *RotatingFileHandler) setFileName(fileName string) {
 
 	fh.File = file
 	fh.FileName = fileName
+	return nil
 }
 
 func (fh *RotatingFileHandler) checkRotate() bool {
@@ -147,7 +169,9 @@ func (fh *RotatingFileHandler) doRotate() {
 	fh.File = nil
 	os.Rename(fh.FileName, nextFileName)
 
-	fh.setFileName(fh.FileName)
+	if err := fh.setFileName(fh.FileName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 	fh.currentLine = 0
 	fh.currentSize = 0
 	fh.setupNextRotateTime()
@@ -161,8 +185,25 @@ func (fh *RotatingFileHandler) setupNextRotateTime() {
 	fh.nextRotateTime = nextTime
 }
 
+// ValidateConfig checks the fields of RotatingFileHandler
+func (fh *RotatingFileHandler) ValidateConfig(config map[string]interface{}) []error {
+	c := glogger.NewConfigChecker("", config)
+	c.String("filename", true)
+	c.Bool("autoRotate", false)
+	c.Bool("daily", false)
+	for _, key := range []string{"maxSize", "maxLine", "backupCount"} {
+		if n, ok := c.Int(key, false); ok && n < 0 {
+			c.Errorf(key, "expected non-negative integer")
+		}
+	}
+	return append(fh.GenericHandler.ValidateConfig(config), c.Errors()...)
+}
+
 // LoadConfig load configuration from a map
 func (fh *RotatingFileHandler) LoadConfig(config map[string]interface{}) error {
+	if err := glogger.JoinConfigErrors(fh.ValidateConfig(config)); err != nil {
+		return err
+	}
 	if err := fh.GenericHandler.LoadConfig(config); err != nil {
 		return err
 	}
@@ -173,9 +214,20 @@ func (fh *RotatingFileHandler) LoadConfig(config map[string]interface{}) error {
 	if err = json.Unmarshal(data, fh); err != nil {
 		return err
 	}
-	if fh.FileName == "" {
-		return fmt.Errorf("'filename' field is required")
+	if err := fh.setFileName(fh.FileName); err != nil {
+		return &glogger.ConfigError{Path: "filename", Msg: err.Error()}
 	}
-	fh.setFileName(fh.FileName)
 	return nil
 }
+
+// ExportConfig exports the rotating options as well as the fields of GenericHandler
+func (fh *RotatingFileHandler) ExportConfig() map[string]interface{} {
+	m := fh.GenericHandler.ExportConfig()
+	m["filename"] = fh.FileName
+	m["autoRotate"] = fh.AutoRotate
+	m["maxSize"] = fh.MaxSize
+	m["maxLine"] = fh.MaxLine
+	m["daily"] = fh.Daily
+	m["backupCount"] = fh.BackupCount
+	return m
+}
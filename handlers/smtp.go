@@ -82,35 +82,38 @@ func (sh *SMTPHandler) Handle(rec *glogger.Record) {
 	}
 }
 
+// ValidateConfig checks the fields of SMTPHandler
+func (sh *SMTPHandler) ValidateConfig(config map[string]interface{}) []error {
+	c := glogger.NewConfigChecker("", config)
+	for _, key := range []string{"address", "username", "password", "to", "subject"} {
+		c.String(key, true)
+	}
+	return append(sh.GenericHandler.ValidateConfig(config), c.Errors()...)
+}
+
 // LoadConfig load configuration from a map
 func (sh *SMTPHandler) LoadConfig(config map[string]interface{}) error {
-	if err := sh.GenericHandler.LoadConfig(config); err != nil {
+	if err := glogger.JoinConfigErrors(sh.ValidateConfig(config)); err != nil {
 		return err
 	}
-	if address, ok := config["address"]; ok {
-		sh.Address = address.(string)
-	} else {
-		return fmt.Errorf("'address' field is required")
-	}
-	if username, ok := config["username"]; ok {
-		sh.Username = username.(string)
-	} else {
-		return fmt.Errorf("'username' field is required")
-	}
-	if password, ok := config["password"]; ok {
-		sh.Password = password.(string)
-	} else {
-		return fmt.Errorf("'password' field is required")
-	}
-	if to, ok := config["to"]; ok {
-		sh.To = strings.Split(to.(string), ";")
-	} else {
-		return fmt.Errorf("'to' field is required")
-	}
-	if subject, ok := config["subject"]; ok {
-		sh.Subject = subject.(string)
-	} else {
-		return fmt.Errorf("'subject' field is required")
+	if err := sh.GenericHandler.LoadConfig(config); err != nil {
+		return err
 	}
+	sh.Address = config["address"].(string)
+	sh.Username = config["username"].(string)
+	sh.Password = config["password"].(string)
+	sh.To = strings.Split(config["to"].(string), ";")
+	sh.Subject = config["subject"].(string)
 	return nil
 }
+
+// ExportConfig exports the fields of SMTPHandler, the password is redacted
+func (sh *SMTPHandler) ExportConfig() map[string]interface{} {
+	m := sh.GenericHandler.ExportConfig()
+	m["address"] = sh.Address
+	m["username"] = sh.Username
+	m["password"] = "******"
+	m["to"] = strings.Join(sh.To, ";")
+	m["subject"] = sh.Subject
+	return m
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LogLevel type, custom levels can be registered by RegisterLevel
+type LogLevel int8
+
+// LogLevel values
+const (
+	DebugLevel LogLevel = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	CriticalLevel
+
+	// NotSetLevel means the Logger inherits the level of its nearest ancestor
+	NotSetLevel LogLevel = math.MaxInt8
+)
+
+// levelsMu guards LevelToString, StringToLevel and longLevelNames
+var levelsMu sync.RWMutex
+
+// LevelToString is a map to translate LogLevel to its short name, which is output by formatters.
+// Use RegisterLevel to add levels.
+var LevelToString = map[LogLevel]string{
+	DebugLevel:    "DBUG",
+	InfoLevel:     "INFO",
+	WarnLevel:     "WARN",
+	ErrorLevel:    "ERRO",
+	CriticalLevel: "CRIT",
+	NotSetLevel:   "NOTSET",
+}
+
+// StringToLevel is a map to translate both short and long level names in upper case to LogLevel type.
+// Use ParseLevel to parse names case-insensitively, and RegisterLevel to add levels.
+var StringToLevel = map[string]LogLevel{
+	"DBUG":     DebugLevel,
+	"DEBUG":    DebugLevel,
+	"INFO":     InfoLevel,
+	"WARN":     WarnLevel,
+	"WARNING":  WarnLevel,
+	"ERRO":     ErrorLevel,
+	"ERROR":    ErrorLevel,
+	"CRIT":     CriticalLevel,
+	"CRITICAL": CriticalLevel,
+	"NOTSET":   NotSetLevel,
+}
+
+// longLevelNames are the names used in configurations
+var longLevelNames = map[LogLevel]string{
+	DebugLevel:    "DEBUG",
+	InfoLevel:     "INFO",
+	WarnLevel:     "WARNING",
+	ErrorLevel:    "ERROR",
+	CriticalLevel: "CRITICAL",
+	NotSetLevel:   "NOTSET",
+}
+
+// RegisterLevel registers a custom level with its short name output by formatters, and its long name
+// used in configurations, like RegisterLevel(-1, "TRAC", "TRACE"). Both names are parsed case-insensitively.
+// It should be called in init functions, before the level is used.
+func RegisterLevel(level LogLevel, shortName, longName string) error {
+	if shortName == "" || longName == "" {
+		return fmt.Errorf("level names can't be empty")
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if name, ok := LevelToString[level]; ok {
+		return fmt.Errorf("level %d has been registered as %s", level, name)
+	}
+	for _, name := range []string{shortName, longName} {
+		if l, ok := StringToLevel[strings.ToUpper(name)]; ok {
+			return fmt.Errorf("level name %s has been registered by level %d", name, l)
+		}
+	}
+	LevelToString[level] = shortName
+	longLevelNames[level] = longName
+	StringToLevel[strings.ToUpper(shortName)] = level
+	StringToLevel[strings.ToUpper(longName)] = level
+	return nil
+}
+
+// ParseLevel return the level with the short or long name, case-insensitively
+func ParseLevel(name string) (LogLevel, error) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	if level, ok := StringToLevel[strings.ToUpper(name)]; ok {
+		return level, nil
+	}
+	return NotSetLevel, fmt.Errorf("unknown log level: %s", name)
+}
+
+// Levels return the registered levels except NotSetLevel in ascending order
+func Levels() []LogLevel {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	levels := make([]LogLevel, 0, len(LevelToString))
+	for level := range LevelToString {
+		if level != NotSetLevel {
+			levels = append(levels, level)
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	return levels
+}
+
+func (level LogLevel) String() string {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	if str, ok := LevelToString[level]; ok {
+		return str
+	}
+	return fmt.Sprintf("unknow LogLeve(%d)", level)
+}
+
+// LevelName return the long name of level used in configurations, like "WARNING"
+func LevelName(level LogLevel) string {
+	levelsMu.RLock()
+	name, ok := longLevelNames[level]
+	levelsMu.RUnlock()
+	if ok {
+		return name
+	}
+	return level.String()
+}
+
+// MarshalText implements encoding.TextMarshaler, the long name is used
+func (level LogLevel) MarshalText() ([]byte, error) {
+	levelsMu.RLock()
+	name, ok := longLevelNames[level]
+	levelsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log level: %d", level)
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, both short and long names are accepted
+func (level *LogLevel) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*level = l
+	return nil
+}
@@ -17,8 +17,10 @@
 package glogger
 
 import (
+	"context"
 	"fmt"
-	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,16 +28,26 @@ import (
 type Logger struct {
 	GroupFilter
 	handlerGroup
-	Name   string
-	Level  LogLevel
-	ch     chan *Record
-	parent *Logger
+	Name      string
+	level     int32        // LogLevel, accessed atomically
+	propagate int32        // 1 if records pass to the handlers of ancestors, accessed atomically
+	async     atomic.Value // *asyncState, replaced while logging by reloading
+	asyncMu   sync.Mutex   // serializes replacing async
+	overflow  int32        // OverflowPolicy, accessed atomically
+	parent    atomic.Value // *Logger, relinked while logging when loggers are registered
+	fields    Fields
+	derived   bool // created by With, shares filters of its parent
+
+	callerSkip int
+	callerMode int32 // CallerMode, accessed atomically
+	stackLevel int32 // LogLevel minus NotSetLevel so zero disables capturing, accessed atomically
 }
 
 // NewLogger return a new Logger with debug level as default.
 func NewLogger() *Logger {
 	l := &Logger{
-		Level: DebugLevel,
+		level:     int32(DebugLevel),
+		propagate: 1,
 	}
 	return l
 }
@@ -55,98 +67,408 @@ func Default() *Logger {
 
 var std = Default()
 
+// With return a derived Logger which attaches the key/value pairs to every record.
+// The derived Logger shares level, filters and handlers with l.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	d := &Logger{
+		Name:      l.Name,
+		level:     int32(NotSetLevel),
+		propagate: 1,
+		fields:    l.fields.With(kv...),
+		derived:   true,
+
+		callerSkip: l.callerSkip,
+	}
+	d.setParent(l)
+	return d
+}
+
 // Debug see details in Logger interface
 func (l *Logger) Debug(f string, v ...interface{}) {
-	l.log(DebugLevel, fmt.Sprintf(f, v...))
+	if l.Enabled(DebugLevel) {
+		l.log(context.Background(), DebugLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
 }
 
 // Info see details in Logger interface
 func (l *Logger) Info(f string, v ...interface{}) {
-	l.log(InfoLevel, fmt.Sprintf(f, v...))
+	if l.Enabled(InfoLevel) {
+		l.log(context.Background(), InfoLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
 }
 
 // Warning see details in Logger interface
 func (l *Logger) Warning(f string, v ...interface{}) {
-	l.log(WarnLevel, fmt.Sprintf(f, v...))
+	if l.Enabled(WarnLevel) {
+		l.log(context.Background(), WarnLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
 }
 
 // Error see details in Logger interface
 func (l *Logger) Error(f string, v ...interface{}) {
-	l.log(ErrorLevel, fmt.Sprintf(f, v...))
+	if l.Enabled(ErrorLevel) {
+		l.log(context.Background(), ErrorLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
 }
 
 // Critical see details in Logger interface
 func (l *Logger) Critical(f string, v ...interface{}) {
-	l.log(CriticalLevel, fmt.Sprintf(f, v...))
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, fmt.Sprintf(f, v...), nil, nil)
+	}
 }
 
-func (l *Logger) log(level LogLevel, msg string) {
-	if level < l.Level {
-		return
+// Log logs in level, which can be a custom level registered by RegisterLevel
+func (l *Logger) Log(level LogLevel, f string, v ...interface{}) {
+	if l.Enabled(level) {
+		l.log(context.Background(), level, fmt.Sprintf(f, v...), nil, nil)
+	}
+}
+
+// Logw logs msg with key/value pairs in level
+func (l *Logger) Logw(level LogLevel, msg string, kv ...interface{}) {
+	if l.Enabled(level) {
+		l.log(context.Background(), level, msg, MakeFields(kv...), nil)
+	}
+}
+
+// Debugw logs msg with key/value pairs in debug level
+func (l *Logger) Debugw(msg string, kv ...interface{}) {
+	if l.Enabled(DebugLevel) {
+		l.log(context.Background(), DebugLevel, msg, MakeFields(kv...), nil)
+	}
+}
+
+// Infow logs msg with key/value pairs in info level
+func (l *Logger) Infow(msg string, kv ...interface{}) {
+	if l.Enabled(InfoLevel) {
+		l.log(context.Background(), InfoLevel, msg, MakeFields(kv...), nil)
+	}
+}
+
+// Warningw logs msg with key/value pairs in warning level
+func (l *Logger) Warningw(msg string, kv ...interface{}) {
+	if l.Enabled(WarnLevel) {
+		l.log(context.Background(), WarnLevel, msg, MakeFields(kv...), nil)
+	}
+}
+
+// Errorw logs msg with key/value pairs in error level
+func (l *Logger) Errorw(msg string, kv ...interface{}) {
+	if l.Enabled(ErrorLevel) {
+		l.log(context.Background(), ErrorLevel, msg, MakeFields(kv...), nil)
+	}
+}
+
+// Criticalw logs msg with key/value pairs in critical level
+func (l *Logger) Criticalw(msg string, kv ...interface{}) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, msg, MakeFields(kv...), nil)
+	}
+}
+
+// DebugFn logs the message returned by fn in debug level, fn is called only if the level is enabled
+func (l *Logger) DebugFn(fn func() string) {
+	if l.Enabled(DebugLevel) {
+		l.log(context.Background(), DebugLevel, fn(), nil, nil)
+	}
+}
+
+// InfoFn logs the message returned by fn in info level like DebugFn
+func (l *Logger) InfoFn(fn func() string) {
+	if l.Enabled(InfoLevel) {
+		l.log(context.Background(), InfoLevel, fn(), nil, nil)
+	}
+}
+
+// WarningFn logs the message returned by fn in warning level like DebugFn
+func (l *Logger) WarningFn(fn func() string) {
+	if l.Enabled(WarnLevel) {
+		l.log(context.Background(), WarnLevel, fn(), nil, nil)
+	}
+}
+
+// ErrorFn logs the message returned by fn in error level like DebugFn
+func (l *Logger) ErrorFn(fn func() string) {
+	if l.Enabled(ErrorLevel) {
+		l.log(context.Background(), ErrorLevel, fn(), nil, nil)
+	}
+}
+
+// CriticalFn logs the message returned by fn in critical level like DebugFn
+func (l *Logger) CriticalFn(fn func() string) {
+	if l.Enabled(CriticalLevel) {
+		l.log(context.Background(), CriticalLevel, fn(), nil, nil)
+	}
+}
+
+// Enabled reports whether records in level would be handled, that is the level isn't lower
+// than EffectiveLevel, and any handler which the records propagate to accepts it by its level.
+// Filters aren't considered.
+func (l *Logger) Enabled(level LogLevel) bool {
+	if level < l.EffectiveLevel() {
+		return false
+	}
+	for lg := l; lg != nil; lg = lg.Parent() {
+		for _, h := range lg.handlerList() {
+			if level >= h.Level() {
+				return true
+			}
+		}
+		if !lg.Propagate() {
+			break
+		}
+	}
+	return false
+}
+
+// Level return the level of the Logger, which may be NotSetLevel. See EffectiveLevel.
+func (l *Logger) Level() LogLevel {
+	return LogLevel(atomic.LoadInt32(&l.level))
+}
+
+// SetLevel set the level of the Logger, it's safe to call while logging
+func (l *Logger) SetLevel(level LogLevel) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// Propagate reports whether records are passed to the handlers of ancestors, true by default
+func (l *Logger) Propagate() bool {
+	return atomic.LoadInt32(&l.propagate) != 0
+}
+
+// SetPropagate set whether records are passed to the handlers of ancestors, it's safe to call while logging
+func (l *Logger) SetPropagate(propagate bool) {
+	var v int32
+	if propagate {
+		v = 1
+	}
+	atomic.StoreInt32(&l.propagate, v)
+}
+
+// Parent return the nearest registered ancestor of the Logger, or nil for root.
+func (l *Logger) Parent() *Logger {
+	parent, _ := l.parent.Load().(*Logger)
+	return parent
+}
+
+func (l *Logger) setParent(parent *Logger) {
+	l.parent.Store(parent)
+}
+
+// EffectiveLevel return the level of the Logger, or the level of its nearest ancestor
+// if it's NotSetLevel. DebugLevel is returned if no level is set in the hierarchy.
+func (l *Logger) EffectiveLevel() LogLevel {
+	for lg := l; lg != nil; lg = lg.Parent() {
+		if level := lg.Level(); level != NotSetLevel {
+			return level
+		}
+	}
+	return DebugLevel
+}
+
+// Handle passes the record to the handlers of the Logger and its ancestors,
+// until the one whose Propagate return false.
+func (l *Logger) Handle(rec *Record) {
+	for lg := l; lg != nil; lg = lg.Parent() {
+		lg.handlerGroup.Handle(rec)
+		if !lg.Propagate() {
+			break
+		}
 	}
+}
+
+// log creates the record and emits it, callers check the level by Enabled first,
+// so the arguments aren't formatted if it's disabled.
+func (l *Logger) log(ctx context.Context, level LogLevel, msg string, fields Fields, err error) {
 	now := time.Now()
-	pc, file, line, ok := runtime.Caller(2)
-	var funcname string
-	if !ok {
-		file = "???"
-		line = 0
-		funcname = "???"
-	} else {
-		funcname = runtime.FuncForPC(pc).Name()
+	var pc uintptr
+	file, funcname, line := "???", "???", 0
+	if l.captureCaller() {
+		pc, file, funcname, line = caller(2+l.callerSkip, nil)
 	}
 	rec := NewRecord(l.Name, now, level, file, funcname, line, msg)
-	if !l.Filter(rec) {
+	rec.PC = pc
+	rec.Err = err
+	if l.captureStackAt(level) {
+		rec.Stack = captureStack(2+l.callerSkip, nil)
+	}
+	if rec.Stack == nil && err != nil {
+		rec.Stack = ErrorStack(err)
+	}
+	l.emit(ctx, rec, fields)
+}
+
+// emit attaches the fields to rec, and dispatches it if it passes the filters.
+func (l *Logger) emit(ctx context.Context, rec *Record, fields Fields) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rec.Context = ctx
+	rec.logger = l
+	rec.Fields = l.fields
+	if ctx != context.Background() {
+		fields = append(contextFields(ctx), fields...)
+	}
+	if len(fields) > 0 {
+		// full slice expression forces a copy, l.fields is shared by records
+		rec.Fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	}
+	if !l.filter(rec) {
 		return
 	}
-	l.Handle(rec)
+	l.dispatch(rec)
 }
 
-func (l *Logger) run() {
-	for {
-		select {
-		case rec := <-l.ch:
-			l.Handle(rec)
+// filter applies the filters of l and of the loggers l is derived from.
+func (l *Logger) filter(rec *Record) bool {
+	for lg := l; lg != nil; lg = lg.Parent() {
+		if !lg.Filter(rec) {
+			return false
+		}
+		if !lg.derived {
+			break
 		}
 	}
+	return true
 }
 
-// LoadConfig loads configuration from map.
-func (l *Logger) LoadConfig(config map[string]interface{}) error {
-	// Load log level, default is DebugLevel
-	if blevel, ok := config["level"]; ok {
-		if level, ok := StringToLevel[blevel.(string)]; ok {
-			l.Level = level
-		} else {
-			return fmt.Errorf("unknown log level: %s", blevel.(string))
+// loggerConfig is the parsed configuration of a Logger
+type loggerConfig struct {
+	level     LogLevel
+	propagate bool
+	handlers  []Handler
+	filters   []Filter
+	async     int
+	overflow  OverflowPolicy
+	caller    CallerMode
+	stack     LogLevel
+}
+
+// parseLoggerConfig checks the fields and resolves the names of handlers and filters.
+// Names aren't resolved if resolve is false.
+func parseLoggerConfig(c *ConfigChecker, resolve bool) (*loggerConfig, error) {
+	lc := &loggerConfig{
+		level:     NotSetLevel,
+		propagate: true,
+		stack:     NotSetLevel,
+	}
+	// Load log level, default is NotSetLevel
+	if level, ok := c.Level("level", false); ok {
+		lc.level = level
+	}
+	// Load propagate, default is true
+	if propagate, ok := c.Bool("propagate", false); ok {
+		lc.propagate = propagate
+	}
+	// Load handlers
+	if names, ok := c.Strings("handlers", false); ok && resolve {
+		for i, name := range names {
+			if handler := GetHandler(name); handler != nil {
+				lc.handlers = append(lc.handlers, handler)
+			} else {
+				c.Errorf(fmt.Sprintf("handlers[%d]", i), "unknown handler name: %s", name)
+			}
 		}
-	} else {
-		l.Level = DebugLevel
 	}
-	// Load handlers, default is StreamHandler
-	if handlers, ok := config["handlers"]; ok && len(handlers.([]interface{})) > 0 {
-		l.ClearHandlers()
-		for _, hname := range handlers.([]interface{}) {
-			if handler := GetHandler(hname.(string)); handler != nil {
-				l.AddHandler(handler)
+	// Load filters
+	if names, ok := c.Strings("filters", false); ok && resolve {
+		for i, name := range names {
+			if filter := GetFilter(name); filter != nil {
+				lc.filters = append(lc.filters, filter)
 			} else {
-				return fmt.Errorf("unknown handler name: %s", hname.(string))
+				c.Errorf(fmt.Sprintf("filters[%d]", i), "unknown filter name: %s", name)
 			}
 		}
+	}
+	// Load async mode, default is synchronous
+	if policy, ok := c.String("overflow", false); ok {
+		if p, ok := StringToOverflowPolicy[policy]; ok {
+			lc.overflow = p
+		} else {
+			c.Errorf("overflow", "unknown overflow policy: %s", policy)
+		}
+	}
+	if size, ok := c.Int("async", false); ok {
+		lc.async = size
+	}
+	// Load caller mode, default is always
+	if mode, ok := c.String("caller", false); ok {
+		if m, ok := StringToCallerMode[mode]; ok {
+			lc.caller = m
+		} else {
+			c.Errorf("caller", "unknown caller mode: %s", mode)
+		}
+	}
+	// Load the level of records capturing stacks, default is disabled
+	if level, ok := c.Level("stackLevel", false); ok {
+		lc.stack = level
+	}
+	return lc, c.Err()
+}
+
+// applyConfig replaces the configuration of the Logger. It can't fail,
+// so loggers can be reconfigured all together after parsing succeeded.
+func (l *Logger) applyConfig(lc *loggerConfig) {
+	l.SetLevel(lc.level)
+	l.SetPropagate(lc.propagate)
+	// default is StreamHandler unless records propagate to ancestors
+	if len(lc.handlers) > 0 {
+		l.SetHandlers(lc.handlers...)
+	} else if l.Parent() != nil && lc.propagate {
+		l.ClearHandlers()
 	} else {
-		h := NewStreamHandler()
-		l.SetHandlers(h)
+		l.SetHandlers(NewStreamHandler())
 	}
-	// Load filters
-	if filters, ok := config["filters"]; ok {
-		if len(filters.([]interface{})) > 0 {
-			for _, fname := range filters.([]interface{}) {
-				if filter := GetFilter(fname.(string)); filter != nil {
-					l.AddFilter(filter)
-				} else {
-					return fmt.Errorf("unknown filter name: %s", fname.(string))
-				}
+	l.SetFilters(lc.filters...)
+	l.SetOverflowPolicy(lc.overflow)
+	l.SetCallerMode(lc.caller)
+	l.SetStackLevel(lc.stack)
+	if lc.async > 0 {
+		l.EnableAsync(lc.async)
+	} else {
+		l.Close()
+	}
+}
+
+// exportConfig exports the configuration with the names of registered handlers and filters
+func (l *Logger) exportConfig() LoggerConfig {
+	propagate := l.Propagate()
+	lc := LoggerConfig{
+		Level:     LevelName(l.Level()),
+		Propagate: &propagate,
+		Filters:   filterNames(l.filterList()),
+	}
+	for _, h := range l.handlerList() {
+		if name := registeredName(handlerRegister, h); name != "" {
+			lc.Handlers = append(lc.Handlers, name)
+		}
+	}
+	if as := l.asyncState(); as != nil {
+		lc.Async = cap(as.ch)
+		for name, policy := range StringToOverflowPolicy {
+			if policy == OverflowPolicy(atomic.LoadInt32(&l.overflow)) {
+				lc.Overflow = name
 			}
 		}
 	}
+	for name, mode := range StringToCallerMode {
+		if mode == CallerMode(atomic.LoadInt32(&l.callerMode)) && mode != CallerAlways {
+			lc.Caller = name
+		}
+	}
+	if level := l.StackLevel(); level != NotSetLevel {
+		lc.StackLevel = LevelName(level)
+	}
+	return lc
+}
+
+// LoadConfig loads configuration from map. The Logger is left untouched if it fails.
+func (l *Logger) LoadConfig(config map[string]interface{}) error {
+	lc, err := parseLoggerConfig(NewConfigChecker("", config), true)
+	if err != nil {
+		return err
+	}
+	l.applyConfig(lc)
 	return nil
 }
@@ -0,0 +1,480 @@
+package glogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestLogger return a Logger writing the records formatted by format into the returned buffer
+func newTestLogger(format string) (*Logger, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	h := NewStreamHandler()
+	h.SetWriter(buf)
+	f := NewDefaultFormatter()
+	f.Fmt = format
+	h.SetFormatter(f)
+	l := NewLogger()
+	l.SetHandlers(h)
+	return l, buf
+}
+
+func TestLoggerHierarchy(t *testing.T) {
+	pool := GetLogger("hierarchy.db.pool")
+	app := GetLogger("hierarchy")
+	db := GetLogger("hierarchy.db")
+	defer func() {
+		UnregisterLogger("hierarchy.db.pool")
+		UnregisterLogger("hierarchy.db")
+		UnregisterLogger("hierarchy")
+	}()
+
+	if pool.Parent() != db || db.Parent() != app || app.Parent() != GetLogger("root") {
+		t.Fatalf("unexpected hierarchy: %v %v %v", pool.Parent(), db.Parent(), app.Parent())
+	}
+
+	app.SetLevel(WarnLevel)
+	if level := pool.EffectiveLevel(); level != WarnLevel {
+		t.Errorf("pool effective level = %v, want %v", level, WarnLevel)
+	}
+
+	var buf bytes.Buffer
+	h := NewStreamHandler()
+	h.SetWriter(&buf)
+	app.SetHandlers(h)
+	app.SetPropagate(false)
+
+	pool.Info("dropped")
+	pool.Error("propagated")
+	if out := buf.String(); strings.Contains(out, "dropped") || !strings.Contains(out, "propagated") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	buf.Reset()
+	db.SetPropagate(false)
+	pool.Error("stopped")
+	if buf.Len() != 0 {
+		t.Errorf("record should not propagate beyond db: %q", buf.String())
+	}
+
+	UnregisterLogger("hierarchy.db")
+	if pool.Parent() != app {
+		t.Errorf("pool should be attached to app after db unregistered")
+	}
+}
+
+// TestLoggerRelink should be run with -race
+func TestLoggerRelink(t *testing.T) {
+	leaf := GetLogger("relink.a.b")
+	defer UnregisterLogger("relink.a.b")
+	h := NewStreamHandler()
+	h.SetWriter(ioutil.Discard)
+	leaf.SetHandlers(h)
+	leaf.SetPropagate(false)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			leaf.Debug("relinking")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		GetLogger("relink.a")
+		UnregisterLogger("relink.a")
+	}
+	<-done
+	if leaf.Parent() != GetLogger("root") {
+		t.Errorf("unexpected parent: %v", leaf.Parent())
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	l, buf := newTestLogger("${msg} ${field:user} | ${fields}")
+
+	derived := l.With("request", 42)
+	derived.Infow("hello", "user", "bob")
+	if out, want := buf.String(), "hello bob | request=42 user=bob\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	buf.Reset()
+	l.SetLevel(ErrorLevel)
+	derived.Infow("disabled")
+	if buf.Len() != 0 {
+		t.Errorf("derived logger should follow level of its parent: %q", buf.String())
+	}
+}
+
+func TestLoggerAsync(t *testing.T) {
+	l, buf := newTestLogger(DefaultFormat)
+	l.EnableAsync(4)
+	for i := 0; i < 100; i++ {
+		l.Info("async %d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 100 {
+		t.Errorf("got %d lines, want 100", n)
+	}
+
+	buf.Reset()
+	l.Info("sync")
+	if !strings.Contains(buf.String(), "sync") {
+		t.Errorf("closed logger should handle records synchronously")
+	}
+}
+
+func TestLoggerAsyncDerived(t *testing.T) {
+	l, buf := newTestLogger(DefaultFormat)
+	d := l.With("k", "v")
+	d.EnableAsync(8)
+	d.SetOverflowPolicy(OverflowDropNewest)
+	if l.asyncState() == nil || d.asyncState() != nil {
+		t.Fatal("derived logger should enable async on its origin")
+	}
+	if policy := OverflowPolicy(atomic.LoadInt32(&l.overflow)); policy != OverflowDropNewest {
+		t.Errorf("overflow policy of origin is %v, want %v", policy, OverflowDropNewest)
+	}
+	d.Info("queued")
+	d.Close()
+	if l.asyncState() != nil {
+		t.Error("closing derived logger should stop the queue of its origin")
+	}
+	if !strings.Contains(buf.String(), "queued") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+// gatedWriter blocks writing until gate is closed
+type gatedWriter struct {
+	gate chan struct{}
+	buf  bytes.Buffer
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	return w.buf.Write(p)
+}
+
+func TestLoggerAsyncHierarchy(t *testing.T) {
+	parent := NewLogger()
+	child := NewLogger()
+	child.setParent(parent)
+	w := &gatedWriter{gate: make(chan struct{})}
+	h := NewStreamHandler()
+	h.SetWriter(w)
+	parent.SetHandlers(h)
+	parent.EnableAsync(16)
+
+	logged := make(chan struct{})
+	go func() {
+		child.Info("queued")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("records of child should go through the queue of parent")
+	}
+	close(w.gate)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				parent.Debug("steady")
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := child.Flush(ctx)
+	close(stop)
+	<-done
+	parent.Close()
+	if err != nil {
+		t.Fatalf("flush should return under steady load: %v", err)
+	}
+	if !strings.Contains(w.buf.String(), "queued") {
+		t.Errorf("unexpected output: %q", w.buf.String())
+	}
+}
+
+type traceIDKey struct{}
+
+func TestLoggerContext(t *testing.T) {
+	RegisterContextExtractor("trace", func(ctx context.Context) Fields {
+		if id, ok := ctx.Value(traceIDKey{}).(string); ok {
+			return MakeFields("trace_id", id)
+		}
+		return nil
+	})
+	defer UnregisterContextExtractor("trace")
+
+	l, buf := newTestLogger("${msg} ${field:trace_id} | ${fields}")
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "abc")
+	ctx = ContextWithFields(ctx, "request", 7)
+	l.With("user", "bob").InfoContext(ctx, "hello %d", 1)
+	if out, want := buf.String(), "hello 1 abc | user=bob request=7 trace_id=abc\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	buf.Reset()
+	UnregisterContextExtractor("trace")
+	var nilCtx context.Context
+	l.InfoContext(nilCtx, "no context")
+	if out := buf.String(); !strings.HasPrefix(out, "no context") || strings.Contains(out, "abc") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	l, buf := newTestLogger("${levelname} ${sfile} ${func} ${msg}")
+
+	restore := RedirectStdLog(l, WarnLevel)
+	log.Printf("first\nsecond")
+	restore()
+	if log.Writer() != os.Stderr {
+		t.Errorf("output of log should be restored")
+	}
+
+	fn := "github.com/Xuyuanp/glogger.TestRedirectStdLog"
+	want := "WARN logger_test.go " + fn + " first\nWARN logger_test.go " + fn + " second\n"
+	if out := buf.String(); out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	buf.Reset()
+	w := l.Writer(ErrorLevel)
+	io.WriteString(w, "part")
+	if buf.Len() != 0 {
+		t.Errorf("incomplete line should be kept: %q", buf.String())
+	}
+	io.WriteString(w, "ial\n")
+	if out := buf.String(); out != "ERRO logger_test.go "+fn+" partial\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	buf.Reset()
+	printWrapper(l.WithCallerSkip(1).Writer(ErrorLevel), "skipped")
+	if out := buf.String(); out != "ERRO logger_test.go "+fn+" skipped\n" {
+		t.Errorf("caller skip should apply to writers: %q", out)
+	}
+}
+
+func printWrapper(w io.Writer, msg string) {
+	fmt.Fprintln(w, msg)
+}
+
+func logHelper(l *Logger, msg string) {
+	Helper()
+	l.Info("%s", msg)
+}
+
+func TestLoggerCaller(t *testing.T) {
+	l, buf := newTestLogger("${func} ${msg}")
+
+	fn := "github.com/Xuyuanp/glogger.TestLoggerCaller"
+	logHelper(l, "helper")
+	func() {
+		l.WithCallerSkip(1).Info("skip")
+	}()
+	d := l.With()
+	d.SetCallerMode(CallerNever)
+	d.Info("never")
+	l.SetCallerMode(CallerAuto)
+	l.Info("auto")
+	if lg, _ := newTestLogger("${msg}"); lg.needsCaller() {
+		t.Errorf("formatter without caller placeholders doesn't need caller")
+	}
+	want := fn + " helper\n" + fn + " skip\n??? never\n" + fn + " auto\n"
+	if out := buf.String(); out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const TraceLevel LogLevel = -1
+	if err := RegisterLevel(TraceLevel, "TRAC", "TRACE"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		levelsMu.Lock()
+		defer levelsMu.Unlock()
+		delete(LevelToString, TraceLevel)
+		delete(longLevelNames, TraceLevel)
+		delete(StringToLevel, "TRAC")
+		delete(StringToLevel, "TRACE")
+	})
+	if err := RegisterLevel(-2, "trace", "FINEST"); err == nil {
+		t.Errorf("duplicate name should be rejected")
+	}
+	for _, name := range []string{"trace", "TRAC", "Warn", "warning"} {
+		if _, err := ParseLevel(name); err != nil {
+			t.Errorf("ParseLevel(%q): %v", name, err)
+		}
+	}
+	var level LogLevel
+	if err := level.UnmarshalText([]byte("trac")); err != nil || level != TraceLevel {
+		t.Errorf("UnmarshalText = %v, %v", level, err)
+	}
+	if text, _ := WarnLevel.MarshalText(); string(text) != "WARNING" {
+		t.Errorf("MarshalText = %s", text)
+	}
+
+	l, buf := newTestLogger("${levelname} ${msg}")
+	l.handlerList()[0].SetLevel(TraceLevel)
+	l.Log(TraceLevel, "dropped")
+	l.SetLevel(TraceLevel)
+	l.Log(TraceLevel, "traced")
+	if out := buf.String(); out != "TRAC traced\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+type closingHandler struct {
+	*StreamHandler
+	flushed, closed bool
+}
+
+func (ch *closingHandler) Flush() error {
+	ch.flushed = true
+	return nil
+}
+
+func (ch *closingHandler) Close() error {
+	ch.closed = true
+	return nil
+}
+
+func TestLoggerFatal(t *testing.T) {
+	defer func(exit func(int)) { ExitFunc = exit }(ExitFunc)
+	code := -1
+	ExitFunc = func(c int) { code = c }
+
+	l := NewLogger()
+	var buf bytes.Buffer
+	h := &closingHandler{StreamHandler: NewStreamHandler()}
+	h.SetWriter(&buf)
+	l.SetHandlers(h)
+	l.EnableAsync(16)
+
+	l.Fatal("fatal %d", 1)
+	if code != 1 || !h.flushed || !h.closed || !strings.Contains(buf.String(), "fatal 1") {
+		t.Errorf("unexpected state after Fatal: code=%d flushed=%v closed=%v output=%q", code, h.flushed, h.closed, buf.String())
+	}
+
+	h.flushed, h.closed = false, false
+	func() {
+		defer func() {
+			if r := recover(); r != "panic" {
+				t.Errorf("recovered %v", r)
+			}
+		}()
+		l.Panicw("panic", "k", "v")
+	}()
+	if !h.flushed || h.closed {
+		t.Errorf("Panic should flush without closing: flushed=%v closed=%v", h.flushed, h.closed)
+	}
+	l.Close()
+}
+
+func TestLoggerStack(t *testing.T) {
+	l, buf := newTestLogger("${msg}${stack}")
+
+	l.Warning("no stack")
+	d := l.With()
+	d.SetStackLevel(ErrorLevel)
+	d.Warning("still no stack")
+	d.Error("stack")
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "no stack" || lines[1] != "still no stack" || lines[2] != "stack" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if lines[3] != "github.com/Xuyuanp/glogger.TestLoggerStack" || !strings.HasPrefix(lines[4], "\t") ||
+		!strings.Contains(lines[4], "logger_test.go:") {
+		t.Errorf("stack should start from the caller: %q", buf.String())
+	}
+}
+
+type joinedErrors []error
+
+func (e joinedErrors) Error() string   { return e[0].Error() + "\n" + e[1].Error() }
+func (e joinedErrors) Unwrap() []error { return e }
+
+type stackError struct {
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string      { return "stacked" }
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+func TestLoggerErr(t *testing.T) {
+	l, buf := newTestLogger("${msg}|${error}")
+
+	inner := errors.New("inner")
+	err := fmt.Errorf("outer: %w", joinedErrors{inner, errors.New("other")})
+	l.ErrorErr(err, "failed %d", 1)
+	expected := "failed 1|outer: inner\nother\n  caused by: inner\nother\n    caused by: inner\n    caused by: other\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	pcs := make([]uintptr, 8)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	l, buf = newTestLogger("${error}${stack}")
+	l.WarningErr(fmt.Errorf("wrapped: %w", &stackError{pcs}), "")
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "wrapped: stacked" || lines[2] != "github.com/Xuyuanp/glogger.TestLoggerErr" {
+		t.Errorf("stack should be taken from the error: %q", buf.String())
+	}
+}
+
+func TestLoggerEnabled(t *testing.T) {
+	parent := NewLogger()
+	l := NewLogger()
+	l.setParent(parent)
+	if l.Enabled(CriticalLevel) {
+		t.Error("no handler should disable all levels")
+	}
+
+	var buf bytes.Buffer
+	h := NewStreamHandler()
+	h.SetWriter(&buf)
+	h.SetLevel(WarnLevel)
+	parent.SetHandlers(h)
+	if l.Enabled(InfoLevel) || !l.Enabled(WarnLevel) {
+		t.Error("levels of propagated handlers should be considered")
+	}
+	l.SetLevel(ErrorLevel)
+	if l.Enabled(WarnLevel) || !l.Enabled(ErrorLevel) {
+		t.Error("level of the logger should be considered")
+	}
+
+	called := false
+	l.WarningFn(func() string { called = true; return "lazy" })
+	if called || buf.Len() > 0 {
+		t.Error("message shouldn't be built if the level is disabled")
+	}
+	l.ErrorFn(func() string { called = true; return "lazy" })
+	if !called || !strings.Contains(buf.String(), "lazy") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -17,20 +17,101 @@
 package glogger
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"path"
 	"time"
 )
 
+// Field is a key/value pair attached to a Record
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields is an ordered collection of Field
+type Fields []Field
+
+// BadKey is the key used for a trailing value without key
+const BadKey = "!BADKEY"
+
+// MakeFields return Fields made from alternating keys and values.
+// A key which is not a string is converted by fmt.Sprint.
+func MakeFields(kv ...interface{}) Fields {
+	if len(kv) == 0 {
+		return nil
+	}
+	fs := make(Fields, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fs = append(fs, Field{Key: BadKey, Value: kv[i]})
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fs = append(fs, Field{Key: key, Value: kv[i+1]})
+	}
+	return fs
+}
+
+// With return new Fields containing fs followed by the key/value pairs.
+func (fs Fields) With(kv ...interface{}) Fields {
+	more := MakeFields(kv...)
+	if len(more) == 0 {
+		return fs
+	}
+	if len(fs) == 0 {
+		return more
+	}
+	merged := make(Fields, 0, len(fs)+len(more))
+	return append(append(merged, fs...), more...)
+}
+
+// Get return the value of the last field with the key.
+func (fs Fields) Get(key string) (interface{}, bool) {
+	for i := len(fs) - 1; i >= 0; i-- {
+		if fs[i].Key == key {
+			return fs[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// String return fields as space separated key=value pairs
+func (fs Fields) String() string {
+	var buf bytes.Buffer
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		fmt.Fprint(&buf, f.Value)
+	}
+	return buf.String()
+}
+
 // Record is a struct contains all the logging information
 type Record struct {
-	Name    string    // logger name
-	Level   LogLevel  // log level
-	Time    time.Time // log time
-	LFile   string    // full file name
-	SFile   string    // final file name
-	Line    int       // line number
-	Func    string    // function name
-	Message string    // log message
+	Name    string          // logger name
+	Level   LogLevel        // log level
+	Time    time.Time       // log time
+	LFile   string          // full file name
+	SFile   string          // final file name
+	Line    int             // line number
+	PC      uintptr         // program counter of the call site, 0 if unknown
+	Stack   []uintptr       // program counters of the stack from the call site, if captured
+	Func    string          // function name
+	Message string          // log message
+	Fields  Fields          // structured key/value pairs
+	Err     error           // error passed to the XErr methods
+	Context context.Context // context passed to the XContext methods, context.Background() by default
+
+	logger  *Logger
+	handler Handler // the Handler whose filters are applying to the record
 }
 
 // NewRecord return a new Record
@@ -43,7 +124,19 @@ func NewRecord(name string, t time.Time, level LogLevel, file string, funcname s
 		Line:    line,
 		Func:    funcname,
 		Message: msg,
+		Context: context.Background(),
 	}
 	rec.SFile = path.Base(file)
 	return rec
 }
+
+// Handler return the Handler whose filters are applying to rec, or nil while the filters
+// of Loggers are applying. Filters shared by handlers use it to tell them apart.
+func (rec *Record) Handler() Handler {
+	return rec.handler
+}
+
+// Logger return the Logger which rec is logged by, or nil if rec isn't logged by a Logger
+func (rec *Record) Logger() *Logger {
+	return rec.logger
+}
@@ -16,12 +16,30 @@
 
 package glogger
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RegisterListener is called after a binding of the Register changed. old is nil if the name
+// is bound for the first time, and v is nil if the name is unbound.
+type RegisterListener func(name string, old, v interface{})
+
+// DuplicateNameError is returned when a name is registered twice
+type DuplicateNameError struct {
+	Name string
+}
+
+func (e *DuplicateNameError) Error() string {
+	return fmt.Sprintf("register name: %s twice", e.Name)
+}
 
 // Register is a thread-safe map
 type Register struct {
-	mapper map[string]interface{}
-	mu     sync.RWMutex
+	mapper    map[string]interface{}
+	listeners []*RegisterListener
+	mu        sync.RWMutex
 }
 
 // NewRegister returns a new register.
@@ -33,23 +51,66 @@ func NewRegister() *Register {
 
 // Register binds the interface and the name. If this name has been registerd, it panics.
 func (r *Register) Register(name string, v interface{}) {
+	if err := r.TryRegister(name, v); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryRegister binds the interface and the name. If this name has been registerd,
+// it returns a *DuplicateNameError.
+func (r *Register) TryRegister(name string, v interface{}) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	if _, dup := r.mapper[name]; dup {
-		panic("register name: " + name + " twice")
+		r.mu.Unlock()
+		return &DuplicateNameError{Name: name}
+	}
+	r.mapper[name] = v
+	listeners := r.listeners
+	r.mu.Unlock()
+	notify(listeners, name, nil, v)
+	return nil
+}
+
+// RegisterOrGet return the interface registered with this name if any, with loaded true.
+// Otherwise, it binds v and the name, and return v with loaded false.
+func (r *Register) RegisterOrGet(name string, v interface{}) (actual interface{}, loaded bool) {
+	r.mu.Lock()
+	if old, ok := r.mapper[name]; ok {
+		r.mu.Unlock()
+		return old, true
 	}
 	r.mapper[name] = v
+	listeners := r.listeners
+	r.mu.Unlock()
+	notify(listeners, name, nil, v)
+	return v, false
+}
+
+// Replace binds the interface and the name whether it has been registered or not.
+// It returns the interface bound before or nil.
+func (r *Register) Replace(name string, v interface{}) interface{} {
+	r.mu.Lock()
+	old := r.mapper[name]
+	r.mapper[name] = v
+	listeners := r.listeners
+	r.mu.Unlock()
+	notify(listeners, name, old, v)
+	return old
 }
 
 // Unregister unbinds the interface and the name. It returns the interface or nil
 func (r *Register) Unregister(name string) interface{} {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if v, ok := r.mapper[name]; ok {
-		delete(r.mapper, name)
-		return v
+	v, ok := r.mapper[name]
+	if !ok {
+		r.mu.Unlock()
+		return nil
 	}
-	return nil
+	delete(r.mapper, name)
+	listeners := r.listeners
+	r.mu.Unlock()
+	notify(listeners, name, v, nil)
+	return v
 }
 
 // Get return an interface registerd with this name.
@@ -58,3 +119,97 @@ func (r *Register) Get(name string) interface{} {
 	defer r.mu.RUnlock()
 	return r.mapper[name]
 }
+
+// Names return all the registered names in order
+func (r *Register) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.mapper))
+	for name := range r.mapper {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Range calls fn for every binding in name order until fn returns false.
+// It ranges over a snapshot, so fn may modify the register.
+func (r *Register) Range(fn func(name string, v interface{}) bool) {
+	m := r.snapshot()
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !fn(name, m[name]) {
+			return
+		}
+	}
+}
+
+// AddListener adds a listener called after every change of bindings,
+// it return a function removing the listener.
+func (r *Register) AddListener(listener RegisterListener) (remove func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// copy on write, so listeners can be called without holding the lock
+	p := &listener
+	listeners := make([]*RegisterListener, len(r.listeners), len(r.listeners)+1)
+	copy(listeners, r.listeners)
+	r.listeners = append(listeners, p)
+	return func() { r.removeListener(p) }
+}
+
+func (r *Register) removeListener(p *RegisterListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	listeners := make([]*RegisterListener, 0, len(r.listeners))
+	for _, l := range r.listeners {
+		if l != p {
+			listeners = append(listeners, l)
+		}
+	}
+	r.listeners = listeners
+}
+
+func notify(listeners []*RegisterListener, name string, old, v interface{}) {
+	for _, listener := range listeners {
+		(*listener)(name, old, v)
+	}
+}
+
+// snapshot return a copy of all the bindings
+func (r *Register) snapshot() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := make(map[string]interface{}, len(r.mapper))
+	for name, v := range r.mapper {
+		m[name] = v
+	}
+	return m
+}
+
+// restore replaces all the bindings with a snapshot, listeners are notified of the differences.
+func (r *Register) restore(m map[string]interface{}) {
+	r.mu.Lock()
+	current := r.mapper
+	r.mapper = m
+	listeners := r.listeners
+	r.mu.Unlock()
+	if len(listeners) == 0 {
+		return
+	}
+	for name, v := range current {
+		if old, ok := m[name]; !ok {
+			notify(listeners, name, v, nil)
+		} else if old != v {
+			notify(listeners, name, v, old)
+		}
+	}
+	for name, v := range m {
+		if _, ok := current[name]; !ok {
+			notify(listeners, name, nil, v)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package glogger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	r := NewRegister()
+	var changes []string
+	remove := r.AddListener(func(name string, old, v interface{}) {
+		changes = append(changes, fmt.Sprintf("%s:%v->%v", name, old, v))
+	})
+
+	if err := r.TryRegister("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := r.TryRegister("a", 2).(*DuplicateNameError); !ok || err.Name != "a" {
+		t.Errorf("duplicate name should fail with DuplicateNameError, got %v", err)
+	}
+	if v, loaded := r.RegisterOrGet("a", 3); !loaded || v != 1 {
+		t.Errorf("RegisterOrGet(a) = %v, %v, want 1, true", v, loaded)
+	}
+	if v, loaded := r.RegisterOrGet("b", 4); loaded || v != 4 || r.Get("b") != 4 {
+		t.Errorf("RegisterOrGet(b) = %v, %v, want 4, false", v, loaded)
+	}
+
+	remove()
+	r.Unregister("a")
+	if got := fmt.Sprint(changes); got != "[a:<nil>->1 b:<nil>->4]" {
+		t.Errorf("listener got %s", got)
+	}
+}
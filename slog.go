@@ -0,0 +1,199 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// LevelToSlog translates LogLevel to slog.Level, CriticalLevel is slog.LevelError+4.
+func LevelToSlog(level LogLevel) slog.Level {
+	switch {
+	case level <= DebugLevel:
+		return slog.LevelDebug
+	case level <= InfoLevel:
+		return slog.LevelInfo
+	case level <= WarnLevel:
+		return slog.LevelWarn
+	case level <= ErrorLevel:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
+
+// LevelFromSlog translates slog.Level to LogLevel, levels between are rounded down.
+func LevelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	case level < slog.LevelError+4:
+		return ErrorLevel
+	}
+	return CriticalLevel
+}
+
+// slogHandler exposes a Logger as slog.Handler
+type slogHandler struct {
+	logger *Logger
+	fields Fields
+	prefix string // the groups joined by "."
+}
+
+// NewSlogHandler return a slog.Handler passing the records to l, so they are filtered
+// and handled as the records logged by l. Attributes are converted to fields,
+// the keys in groups are prefixed with the group names joined by ".".
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled reports whether the level is enabled by the Logger and its handlers, see Logger.Enabled
+func (sh *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return sh.logger.Enabled(LevelFromSlog(level))
+}
+
+// Handle converts the slog.Record to Record and passes it to the Logger
+func (sh *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	file, funcname, line := "???", "???", 0
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file, funcname, line = frame.File, frame.Function, frame.Line
+	}
+	rec := NewRecord(sh.logger.Name, t, LevelFromSlog(r.Level), file, funcname, line, r.Message)
+	rec.PC = r.PC
+	if sh.logger.captureStackAt(rec.Level) {
+		rec.Stack = slogStack(r.PC)
+	}
+	fields := sh.fields[:len(sh.fields):len(sh.fields)]
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, sh.prefix, a)
+		return true
+	})
+	sh.logger.emit(ctx, rec, fields)
+	return nil
+}
+
+// slogStack return the stack of the current goroutine from the frame of pc, the caller recorded by slog.
+// Only the frames of log/slog are trimmed if pc isn't on the stack.
+func slogStack(pc uintptr) []uintptr {
+	pcs := captureStack(1, func(funcname string) bool { return funcPackage(funcname) == "log/slog" })
+	for i := range pcs {
+		if pcs[i] == pc {
+			return pcs[i:]
+		}
+	}
+	return pcs
+}
+
+// WithAttrs return a slog.Handler with the attributes converted to fields
+func (sh *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return sh
+	}
+	fields := sh.fields[:len(sh.fields):len(sh.fields)]
+	for _, a := range attrs {
+		fields = appendAttr(fields, sh.prefix, a)
+	}
+	return &slogHandler{logger: sh.logger, fields: fields, prefix: sh.prefix}
+}
+
+// WithGroup return a slog.Handler prefixing the keys of attributes with the group name
+func (sh *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return sh
+	}
+	return &slogHandler{logger: sh.logger, fields: sh.fields, prefix: sh.prefix + name + "."}
+}
+
+// appendAttr appends the attribute as fields, groups are flattened with prefixed keys.
+func appendAttr(fields Fields, prefix string, a slog.Attr) Fields {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+// SlogForwardHandler is a Handler forwarding the records to a slog.Handler.
+// Level and filters of the SlogForwardHandler are applied before the ones of the slog.Handler.
+type SlogForwardHandler struct {
+	*GenericHandler
+	handler slog.Handler
+}
+
+// NewSlogForwardHandler return a new SlogForwardHandler forwarding to h
+func NewSlogForwardHandler(h slog.Handler) *SlogForwardHandler {
+	return &SlogForwardHandler{
+		GenericHandler: NewHandler(),
+		handler:        h,
+	}
+}
+
+// NeedsCaller return true, as the slog.Handler may use the PC of records
+func (sh *SlogForwardHandler) NeedsCaller() bool {
+	return true
+}
+
+// Handle converts the Record to slog.Record and passes it to the slog.Handler,
+// Err of the Record is added as the attribute "error".
+func (sh *SlogForwardHandler) Handle(rec *Record) {
+	ctx := rec.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := LevelToSlog(rec.Level)
+	if !sh.handler.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(rec.Time, level, rec.Message, rec.PC)
+	for _, f := range rec.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if rec.Err != nil {
+		r.AddAttrs(slog.Any("error", rec.Err))
+	}
+	if err := sh.handler.Handle(ctx, r); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build go1.21
+// +build go1.21
+
+package glogger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	l, buf := newTestLogger("${levelname} ${sfile} ${msg} | ${fields}")
+	l.SetLevel(InfoLevel)
+
+	sl := slog.New(NewSlogHandler(l)).With("a", 1).WithGroup("g")
+	sl.Debug("dropped")
+	sl.Warn("hello", "b", 2, slog.Group("c", "d", true))
+	if out, want := buf.String(), "WARN slog_test.go hello | a=1 g.b=2 g.c.d=true\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestSlogHandlerStack(t *testing.T) {
+	l, buf := newTestLogger("${msg}${stack}")
+	l.SetStackLevel(ErrorLevel)
+
+	sl := slog.New(NewSlogHandler(l))
+	sl.Warn("no stack")
+	sl.Error("stack")
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "no stack" || lines[1] != "stack" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if lines[2] != "github.com/Xuyuanp/glogger.TestSlogHandlerStack" || !strings.Contains(lines[3], "slog_test.go:") {
+		t.Errorf("stack should start from the caller: %q", buf.String())
+	}
+}
+
+func TestSlogForwardHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger()
+	l.SetHandlers(NewSlogForwardHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	l.Debugw("dropped")
+	l.Criticalw("hello", "k", "v")
+	out := buf.String()
+	if strings.Contains(out, "dropped") || !strings.Contains(out, `level=ERROR+4 msg=hello k=v`) {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	buf.Reset()
+	l.ErrorErr(errors.New("boom"), "failed")
+	if out := buf.String(); !strings.Contains(out, `msg=failed error=boom`) {
+		t.Errorf("error should be forwarded: %q", out)
+	}
+}
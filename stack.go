@@ -0,0 +1,116 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// maxStackDepth is the maximum number of frames captured
+const maxStackDepth = 64
+
+// captureStack return the program counters skip levels above the caller of captureStack like caller.
+// The leading frames of helpers and the ones for which skipFrame return true are trimmed.
+func captureStack(skip int, skipFrame func(funcname string) bool) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+	for len(pcs) > 0 {
+		frame, _ := runtime.CallersFrames(pcs[:1]).Next()
+		if !isHelper(frame.Function) && (skipFrame == nil || !skipFrame(frame.Function)) {
+			break
+		}
+		pcs = pcs[1:]
+	}
+	return pcs
+}
+
+// StackFrames return the frames of Stack, or nil if the stack isn't captured
+func (rec *Record) StackFrames() []runtime.Frame {
+	if len(rec.Stack) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(rec.Stack)
+	for {
+		frame, more := it.Next()
+		if frame.Function != "runtime.goexit" {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// StackTrace return Stack in the format of goroutine traces, a function per line followed by
+// its file and line indented by a tab. It's empty if the stack isn't captured.
+func (rec *Record) StackTrace() string {
+	var buf bytes.Buffer
+	for i, frame := range rec.StackFrames() {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		writeFrame(&buf, frame, "", "", "")
+	}
+	return buf.String()
+}
+
+// writeFrame writes the frame wrapped by the escape codes of colors, reset ends them
+func writeFrame(buf *bytes.Buffer, frame runtime.Frame, funcColor, fileColor, reset string) {
+	buf.WriteString(funcColor)
+	buf.WriteString(frame.Function)
+	buf.WriteString(reset)
+	buf.WriteString("\n\t")
+	buf.WriteString(fileColor)
+	buf.WriteString(frame.File)
+	buf.WriteByte(':')
+	buf.WriteString(strconv.Itoa(frame.Line))
+	buf.WriteString(reset)
+}
+
+// WriteStack writes the stack of rec as the placeholder ${stack} into buf, the escape codes of
+// funcColor and fileColor wrap function names and file names which are followed by reset.
+// Nothing is written if the stack isn't captured, or else it starts with a newline.
+func WriteStack(buf *bytes.Buffer, rec *Record, funcColor, fileColor, reset string) {
+	for _, frame := range rec.StackFrames() {
+		buf.WriteByte('\n')
+		writeFrame(buf, frame, funcColor, fileColor, reset)
+	}
+}
+
+// StackLevel return the lowest level of records whose stacks are captured,
+// a Logger derived by With shares it with the Logger it's derived from.
+func (l *Logger) StackLevel() LogLevel {
+	return LogLevel(atomic.LoadInt32(&l.origin().stackLevel) + int32(NotSetLevel))
+}
+
+// SetStackLevel set the lowest level of records whose stacks are captured,
+// NotSetLevel disables capturing which is the default.
+// On a Logger derived by With, it's set on the Logger it's derived from.
+func (l *Logger) SetStackLevel(level LogLevel) {
+	atomic.StoreInt32(&l.origin().stackLevel, int32(level)-int32(NotSetLevel))
+}
+
+// captureStackAt reports whether the stacks of records in level should be captured
+func (l *Logger) captureStackAt(level LogLevel) bool {
+	threshold := l.StackLevel()
+	return threshold != NotSetLevel && level >= threshold
+}
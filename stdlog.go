@@ -0,0 +1,134 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineWriter splits the bytes written into lines, and logs each of them as a record.
+type lineWriter struct {
+	logger *Logger
+	level  LogLevel
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// Writer return an io.Writer logging each line written in level.
+// A line without the trailing newline is kept until the newline is written.
+// The caller of the record is the first function on the stack outside the packages
+// writing to it, like log, fmt and io, skipping the frames of WithCallerSkip as well.
+func (l *Logger) Writer(level LogLevel) io.Writer {
+	return &lineWriter{logger: l, level: level}
+}
+
+// Write logs the complete lines in p
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf[:i]), "\r")
+		w.buf = w.buf[i+1:]
+		if line != "" {
+			w.log(line)
+		}
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) log(msg string) {
+	if !w.logger.Enabled(w.level) {
+		return
+	}
+	var pc uintptr
+	file, funcname, line := "???", "???", 0
+	if w.logger.captureCaller() {
+		pc, file, funcname, line = caller(2, skipWriterFrames(w.logger.callerSkip))
+	}
+	rec := NewRecord(w.logger.Name, time.Now(), w.level, file, funcname, line, msg)
+	rec.PC = pc
+	if w.logger.captureStackAt(w.level) {
+		rec.Stack = captureStack(2, skipWriterFrames(w.logger.callerSkip))
+	}
+	w.logger.emit(context.Background(), rec, nil)
+}
+
+// writerPackages are the packages skipped looking for the caller of lineWriter
+var writerPackages = map[string]bool{
+	"bufio": true,
+	"fmt":   true,
+	"io":    true,
+	"log":   true,
+	"os":    true,
+}
+
+// isWriterFrame reports whether the function is lineWriter's or in writerPackages
+func isWriterFrame(funcname string) bool {
+	return strings.Contains(funcname, ".(*lineWriter).") || writerPackages[funcPackage(funcname)]
+}
+
+// skipWriterFrames return a function skipping the frames of writers, and then n more frames
+// like the callerSkip of Logger. It's used for a single walk of the stack.
+func skipWriterFrames(n int) func(funcname string) bool {
+	return func(funcname string) bool {
+		if isWriterFrame(funcname) {
+			return true
+		}
+		if n > 0 {
+			n--
+			return true
+		}
+		return false
+	}
+}
+
+// funcPackage return the package path of the full function name,
+// "log" for "log.(*Logger).Printf".
+func funcPackage(funcname string) string {
+	i := strings.LastIndex(funcname, "/") + 1
+	if j := strings.Index(funcname[i:], "."); j >= 0 {
+		return funcname[:i+j]
+	}
+	return funcname
+}
+
+// RedirectStdLog redirects the output of the standard log package to l in level,
+// and clears the flags of it as the formatters output time and caller.
+// The returned function restores the output and flags.
+func RedirectStdLog(l *Logger, level LogLevel) func() {
+	writer, flags := log.Writer(), log.Flags()
+	log.SetOutput(l.Writer(level))
+	log.SetFlags(0)
+	return func() {
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// Template is a format string compiled into literal texts and ${...} placeholders
+type Template struct {
+	src      string
+	segments []segment
+}
+
+type segment struct {
+	text        string // literal text, or placeholder name
+	placeholder bool
+}
+
+// CompileTemplate parses the format string. Placeholders are ${name} or ${name:arg},
+// anything else is literal text.
+func CompileTemplate(format string) *Template {
+	t := &Template{src: format}
+	literal := 0
+	for i := 0; i < len(format); {
+		start := strings.Index(format[i:], "${")
+		if start < 0 {
+			break
+		}
+		start += i
+		end := strings.IndexByte(format[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		name := format[start+2 : end]
+		if !validPlaceholder(name) {
+			i = start + 2
+			continue
+		}
+		if literal < start {
+			t.segments = append(t.segments, segment{text: format[literal:start]})
+		}
+		t.segments = append(t.segments, segment{text: name, placeholder: true})
+		literal = end + 1
+		i = literal
+	}
+	if literal < len(format) {
+		t.segments = append(t.segments, segment{text: format[literal:]})
+	}
+	return t
+}
+
+// validPlaceholder reports if name matches \w+(:[^}]+)?
+func validPlaceholder(name string) bool {
+	word := name
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		if i == len(name)-1 {
+			return false
+		}
+		word = name[:i]
+	}
+	if word == "" {
+		return false
+	}
+	for _, r := range word {
+		if r != '_' && !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+// String return the source format string
+func (t *Template) String() string {
+	return t.src
+}
+
+// Placeholders return the names of placeholders in order
+func (t *Template) Placeholders() []string {
+	var names []string
+	for _, seg := range t.segments {
+		if seg.placeholder {
+			names = append(names, seg.text)
+		}
+	}
+	return names
+}
+
+// Execute writes the template into buf. fn writes the value of a placeholder and
+// returns false if it's unknown, which is written as is.
+func (t *Template) Execute(buf *bytes.Buffer, fn func(buf *bytes.Buffer, name string) bool) {
+	for _, seg := range t.segments {
+		if !seg.placeholder {
+			buf.WriteString(seg.text)
+			continue
+		}
+		if !fn(buf, seg.text) {
+			buf.WriteString("${")
+			buf.WriteString(seg.text)
+			buf.WriteByte('}')
+		}
+	}
+}
@@ -0,0 +1,437 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigError is an error at a location of the configuration, like handlers.file.level
+type ConfigError struct {
+	Path    string
+	Msg     string
+	Warning bool // the configuration can still be loaded
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return e.Path + ": " + e.Msg
+}
+
+// ConfigErrors is a list of errors of a configuration
+type ConfigErrors []error
+
+func (es ConfigErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// JoinConfigErrors return nil if errs is empty, or errs as ConfigErrors
+func JoinConfigErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return ConfigErrors(errs)
+}
+
+// ConfigValidator is implemented by ConfigLoaders which can check a configuration without
+// loading it. Handlers should implement it, as loading them may open files or connections,
+// while filters and formatters without it are checked by loading throwaway instances.
+type ConfigValidator interface {
+	ValidateConfig(config map[string]interface{}) []error
+}
+
+func joinPath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	if child == "" {
+		return parent
+	}
+	if strings.HasPrefix(child, "[") {
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+// prefixErrors prepends path to the paths of ConfigErrors, other errors are converted to ConfigError.
+func prefixErrors(path string, errs ...error) []error {
+	var prefixed []error
+	for _, err := range errs {
+		switch e := err.(type) {
+		case ConfigErrors:
+			prefixed = append(prefixed, prefixErrors(path, e...)...)
+		case *ConfigError:
+			prefixed = append(prefixed, &ConfigError{Path: joinPath(path, e.Path), Msg: e.Msg, Warning: e.Warning})
+		default:
+			prefixed = append(prefixed, &ConfigError{Path: path, Msg: err.Error()})
+		}
+	}
+	return prefixed
+}
+
+// ConfigChecker checks the fields of a config section and collects errors with their paths
+type ConfigChecker struct {
+	Path   string
+	Config map[string]interface{}
+	errs   []error
+}
+
+// NewConfigChecker return a new ConfigChecker of the config section at path
+func NewConfigChecker(path string, config map[string]interface{}) *ConfigChecker {
+	return &ConfigChecker{
+		Path:   path,
+		Config: config,
+	}
+}
+
+// Errorf adds an error of the field
+func (c *ConfigChecker) Errorf(key string, format string, v ...interface{}) {
+	c.errs = append(c.errs, &ConfigError{Path: joinPath(c.Path, key), Msg: fmt.Sprintf(format, v...)})
+}
+
+// Add adds errors found by another checker, their paths are prefixed with the path of c
+func (c *ConfigChecker) Add(errs ...error) {
+	c.errs = append(c.errs, prefixErrors(c.Path, errs...)...)
+}
+
+// Errors return the errors collected
+func (c *ConfigChecker) Errors() []error {
+	return c.errs
+}
+
+// Err return the errors collected as ConfigErrors, or nil
+func (c *ConfigChecker) Err() error {
+	return JoinConfigErrors(c.errs)
+}
+
+func (c *ConfigChecker) get(key string, required bool) (interface{}, bool) {
+	v, ok := c.Config[key]
+	if !ok && required {
+		c.Errorf(key, "field is required")
+	}
+	return v, ok
+}
+
+// String return the string field
+func (c *ConfigChecker) String(key string, required bool) (string, bool) {
+	v, ok := c.get(key, required)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	if !ok {
+		c.Errorf(key, "expected string")
+	}
+	return s, ok
+}
+
+// Float return the number field
+func (c *ConfigChecker) Float(key string, required bool) (float64, bool) {
+	v, ok := c.get(key, required)
+	if !ok {
+		return 0, false
+	}
+	f, ok := v.(float64)
+	if !ok {
+		c.Errorf(key, "expected number")
+	}
+	return f, ok
+}
+
+// Int return the number field which must be an integer
+func (c *ConfigChecker) Int(key string, required bool) (int, bool) {
+	f, ok := c.Float(key, required)
+	if ok && f != float64(int(f)) {
+		c.Errorf(key, "expected integer")
+		return 0, false
+	}
+	return int(f), ok
+}
+
+// Bool return the boolean field
+func (c *ConfigChecker) Bool(key string, required bool) (bool, bool) {
+	v, ok := c.get(key, required)
+	if !ok {
+		return false, false
+	}
+	b, ok := v.(bool)
+	if !ok {
+		c.Errorf(key, "expected boolean")
+	}
+	return b, ok
+}
+
+// Strings return the field which must be a list of strings
+func (c *ConfigChecker) Strings(key string, required bool) ([]string, bool) {
+	v, ok := c.get(key, required)
+	if !ok {
+		return nil, false
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		c.Errorf(key, "expected list of strings")
+		return nil, false
+	}
+	strs := make([]string, 0, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			c.Errorf(fmt.Sprintf("%s[%d]", key, i), "expected string")
+			return nil, false
+		}
+		strs = append(strs, s)
+	}
+	return strs, true
+}
+
+// Map return the object field
+func (c *ConfigChecker) Map(key string, required bool) (map[string]interface{}, bool) {
+	v, ok := c.get(key, required)
+	if !ok {
+		return nil, false
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		c.Errorf(key, "expected object")
+	}
+	return m, ok
+}
+
+// Level return the field which must be a level name, parsed by ParseLevel
+func (c *ConfigChecker) Level(key string, required bool) (LogLevel, bool) {
+	name, ok := c.String(key, required)
+	if !ok {
+		return DebugLevel, false
+	}
+	level, err := ParseLevel(name)
+	if err != nil {
+		c.Errorf(key, "%s", err)
+		return DebugLevel, false
+	}
+	return level, true
+}
+
+// Duration return the field which must be a duration string like "1m30s"
+func (c *ConfigChecker) Duration(key string, required bool) (time.Duration, bool) {
+	s, ok := c.String(key, required)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		c.Errorf(key, "invalid duration: %s", s)
+		return 0, false
+	}
+	return d, true
+}
+
+type configMap map[string]map[string]map[string]interface{}
+
+var configSections = []string{"filters", "formatters", "handlers", "loggers"}
+
+var defaultBuilders = map[string]string{
+	"formatters": "github.com/Xuyuanp/glogger.DefaultFormatter",
+	"handlers":   "github.com/Xuyuanp/glogger.StreamHandler",
+}
+
+// parseConfig parses the json format configuration into sections, and fills the default builders.
+func parseConfig(config []byte) (configMap, []error) {
+	var raw interface{}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return nil, []error{err}
+	}
+	root, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, []error{&ConfigError{Msg: "expected object"}}
+	}
+	var errs []error
+	cm := make(configMap)
+	for section, v := range root {
+		if !isConfigSection(section) {
+			errs = append(errs, &ConfigError{Path: section, Msg: "unknown section"})
+			continue
+		}
+		entries, ok := v.(map[string]interface{})
+		if !ok {
+			errs = append(errs, &ConfigError{Path: section, Msg: "expected object"})
+			continue
+		}
+		cm[section] = make(map[string]map[string]interface{})
+		for name, conf := range entries {
+			m, ok := conf.(map[string]interface{})
+			if !ok {
+				errs = append(errs, &ConfigError{Path: joinPath(section, name), Msg: "expected object"})
+				continue
+			}
+			if builder, ok := defaultBuilders[section]; ok {
+				if b, ok := m["builder"]; !ok || b == "default" {
+					m["builder"] = builder
+				}
+			}
+			cm[section][name] = m
+		}
+	}
+	return cm, errs
+}
+
+func isConfigSection(name string) bool {
+	for _, section := range configSections {
+		if section == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfig checks the json format configuration without loading it, and return all the
+// problems found. Unused filters, formatters and handlers are reported with Warning true.
+func ValidateConfig(config []byte, opts ...ConfigOption) []error {
+	_, errs := checkConfig(config, newConfigOptions(opts))
+	return errs
+}
+
+// checkConfig parses the configuration, applies the environment variables and validates it.
+func checkConfig(config []byte, options *configOptions) (configMap, []error) {
+	cm, errs := parseConfig(config)
+	if cm == nil {
+		return nil, errs
+	}
+	if options.env {
+		for section, entries := range cm {
+			for name, conf := range entries {
+				_, es := interpolateEnv(joinPath(section, name), conf)
+				errs = append(errs, es...)
+			}
+		}
+		errs = append(errs, cm.applyEnvOverrides()...)
+	}
+	return cm, append(errs, cm.validate()...)
+}
+
+func (cm configMap) validate() []error {
+	var errs []error
+	used := make(map[string]bool)
+	// ref checks the names referred by the field exist, type errors are reported by field checks
+	ref := func(c *ConfigChecker, key, section string, exists func(string) bool) {
+		check := func(path, name string) {
+			used[section+"."+name] = true
+			if _, ok := cm[section][name]; !ok && !exists(name) {
+				c.Errorf(path, "unknown %s name: %s", strings.TrimSuffix(section, "s"), name)
+			}
+		}
+		switch v := c.Config[key].(type) {
+		case string:
+			check(key, v)
+		case []interface{}:
+			for i, item := range v {
+				if name, ok := item.(string); ok {
+					check(fmt.Sprintf("%s[%d]", key, i), name)
+				}
+			}
+		}
+	}
+	filterExists := func(name string) bool { return GetFilter(name) != nil }
+	formatterExists := func(name string) bool { return GetFormatter(name) != nil }
+	handlerExists := func(name string) bool { return GetHandler(name) != nil }
+
+	for _, section := range []string{"filters", "formatters", "handlers"} {
+		for _, name := range sortedNames(cm[section]) {
+			conf := cm[section][name]
+			path := joinPath(section, name)
+			c := NewConfigChecker(path, conf)
+			if section == "handlers" {
+				ref(c, "formatter", "formatters", formatterExists)
+				ref(c, "filters", "filters", filterExists)
+			}
+			errs = append(errs, c.Errors()...)
+			errs = append(errs, validateLoader(section, path, conf)...)
+		}
+	}
+	for _, name := range sortedNames(cm["loggers"]) {
+		path := joinPath("loggers", name)
+		c := NewConfigChecker(path, cm["loggers"][name])
+		ref(c, "handlers", "handlers", handlerExists)
+		ref(c, "filters", "filters", filterExists)
+		parseLoggerConfig(c, false)
+		errs = append(errs, c.Errors()...)
+	}
+	for _, section := range []string{"filters", "formatters", "handlers"} {
+		for _, name := range sortedNames(cm[section]) {
+			if !used[section+"."+name] {
+				errs = append(errs, &ConfigError{Path: joinPath(section, name), Msg: "defined but not used", Warning: true})
+			}
+		}
+	}
+	return errs
+}
+
+// validateLoader checks the builder and the fields of a filter, formatter or handler section.
+func validateLoader(section, path string, conf map[string]interface{}) []error {
+	c := NewConfigChecker(path, conf)
+	builderName, ok := c.String("builder", true)
+	if !ok {
+		return c.Errors()
+	}
+	builder := GetConfigLoaderBuilder(builderName)
+	if builder == nil {
+		c.Errorf("builder", "unknown builder name: %s", builderName)
+		return c.Errors()
+	}
+	loader := builder()
+	var isKind bool
+	switch section {
+	case "filters":
+		_, isKind = loader.(Filter)
+	case "formatters":
+		_, isKind = loader.(Formatter)
+	case "handlers":
+		_, isKind = loader.(Handler)
+	}
+	if !isKind {
+		c.Errorf("builder", "%s doesn't build %s", builderName, section)
+		return c.Errors()
+	}
+	if v, ok := loader.(ConfigValidator); ok {
+		return prefixErrors(path, v.ValidateConfig(conf)...)
+	}
+	if section == "handlers" {
+		return nil
+	}
+	if err := loader.LoadConfig(conf); err != nil {
+		return prefixErrors(path, err)
+	}
+	return nil
+}
+
+func sortedNames(entries map[string]map[string]interface{}) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2014 Xuyuan Pang <xuyuanp # gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package glogger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// WatchInterval is the interval of checking if a watched config file is modified
+var WatchInterval = 2 * time.Second
+
+// ConfigWatcher reloads a config file when it's modified or the process receives SIGHUP.
+// Filters, formatters and handlers with the same names are replaced unless another ConfigMode
+// is given, loggers are reconfigured in place, so the *Logger got before stay valid.
+// If reloading fails, the working configuration is kept and the error is reported.
+type ConfigWatcher struct {
+	fileName string
+	opts     []ConfigOption
+	modTime  time.Time
+	size     int64
+	errors   chan error
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// WatchConfigFile loads the config file and return a ConfigWatcher watching it.
+func WatchConfigFile(fileName string, opts ...ConfigOption) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{
+		fileName: fileName,
+		opts:     append([]ConfigOption{WithConfigMode(MergeMode)}, opts...),
+		errors:   make(chan error, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.modified()
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Reload loads the config file immediately
+func (w *ConfigWatcher) Reload() error {
+	return LoadConfigFromFile(w.fileName, w.opts...)
+}
+
+// Errors return a channel of errors occurred while reloading. Errors are also printed
+// to stderr, and dropped if the channel isn't drained.
+func (w *ConfigWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Stop stops watching
+func (w *ConfigWatcher) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+// modified reports if the modification time or size of the file changed since last call
+func (w *ConfigWatcher) modified() bool {
+	info, err := os.Stat(w.fileName)
+	if err != nil {
+		return false
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+	return true
+}
+
+func (w *ConfigWatcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	signals := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(signals, reloadSignals...)
+		defer signal.Stop(signals)
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if !w.modified() {
+				continue
+			}
+		case <-signals:
+			w.modified()
+		case <-w.stop:
+			return
+		}
+		if err := w.Reload(); err != nil {
+			w.report(fmt.Errorf("reload %s: %v", w.fileName, err))
+		}
+	}
+}
+
+func (w *ConfigWatcher) report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	select {
+	case w.errors <- err:
+	default:
+	}
+}